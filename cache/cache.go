@@ -17,6 +17,17 @@ import (
 type cache struct {
 	sync.Mutex
 	objects map[string]interface{}
+	// loading holds the loads in progress. The lock isn't held while an
+	// object loads, so a load function can use the cache itself, and other
+	// keys can be read meanwhile; concurrent loads of one key wait for the
+	// first.
+	loading map[string]*pendingLoad
+}
+
+type pendingLoad struct {
+	done chan struct{}
+	obj  interface{}
+	err  error
 }
 
 type loadFunc func(cfg *config.Config, key string) (interface{}, error)
@@ -27,6 +38,8 @@ type readFunc func(data []byte) (interface{}, error)
 var GlobalObjectCache *cache
 
 func (c *cache) Keys() []string {
+	c.Lock()
+	defer c.Unlock()
 	keys := make([]string, 0, len(c.objects))
 	for k := range c.objects {
 		keys = append(keys, k)
@@ -34,36 +47,40 @@ func (c *cache) Keys() []string {
 	return keys
 }
 
-func (c *cache) load(cfg *config.Config, key string, loadFunc loadFunc) error {
-	log.Debug().Str("key", key).Msg("loading into cache")
-
-	obj, err := loadFunc(cfg, key)
-	if err != nil {
-		return err
-	}
-	c.objects[key] = obj
-
-	return nil
+func (c *cache) lookup(key string) (interface{}, bool) {
+	c.Lock()
+	defer c.Unlock()
+	obj, ok := c.objects[key]
+	return obj, ok
 }
 
-func (c *cache) get(cfg *config.Config, key string, loadFunc loadFunc, needToLock bool) (interface{}, error) {
-
-	var ok bool
-	var obj interface{}
-	if needToLock {
-		c.Lock()
-		defer c.Unlock()
+func (c *cache) get(cfg *config.Config, key string, loadFunc loadFunc) (interface{}, error) {
+	c.Lock()
+	if obj, ok := c.objects[key]; ok {
+		c.Unlock()
+		log.Debug().Str("key", key).Msg("getting obj from cache")
+		return obj, nil
 	}
-	if obj, ok = c.objects[key]; !ok {
-		err := c.load(cfg, key, loadFunc)
-		if err != nil {
-			return nil, err
-		}
-		return c.objects[key], nil
+	if p, ok := c.loading[key]; ok {
+		c.Unlock()
+		<-p.done
+		return p.obj, p.err
 	}
-	log.Debug().Str("key", key).Msg("getting obj from cache")
+	p := &pendingLoad{done: make(chan struct{})}
+	c.loading[key] = p
+	c.Unlock()
 
-	return obj, nil
+	log.Debug().Str("key", key).Msg("loading into cache")
+	p.obj, p.err = loadFunc(cfg, key)
+
+	c.Lock()
+	delete(c.loading, key)
+	if p.err == nil {
+		c.objects[key] = p.obj
+	}
+	c.Unlock()
+	close(p.done)
+	return p.obj, p.err
 }
 
 func (c *cache) put(key string, obj interface{}) {
@@ -73,23 +90,19 @@ func (c *cache) put(key string, obj interface{}) {
 }
 
 func init() {
-	GlobalObjectCache = &cache{objects: make(map[string]interface{})}
+	GlobalObjectCache = &cache{
+		objects: make(map[string]interface{}),
+		loading: make(map[string]*pendingLoad),
+	}
 }
 
 func Load(cfg *config.Config, name string, loadFunc loadFunc) (interface{}, error) {
-	return GlobalObjectCache.get(cfg, name, loadFunc, true)
+	return GlobalObjectCache.get(cfg, name, loadFunc)
 }
 
 func Open(filename string) (io.ReadCloser, int, error) {
-	// Most of the time, it seems we are already holding the lock here.
-	// It would deadlock to lock again, so we avoid it.
-	// Hopefully it works.
-
-	cached, err := GlobalObjectCache.get(nil, "file:"+filename,
-		func(*config.Config, string) (interface{}, error) {
-			return nil, os.ErrNotExist
-		}, false)
-	if err != nil {
+	cached, ok := GlobalObjectCache.lookup("file:" + filename)
+	if !ok {
 		// Intentionally not caching.
 		log.Debug().Str("filename", filename).Msg("not cache, opening from filesystem")
 		f, err := os.Open(filename)
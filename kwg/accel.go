@@ -0,0 +1,70 @@
+package kwg
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// nodeAccel holds, for every node, the tiles of the arc list that starts at
+// it: children has a bit for every tile that has an arc, and accepts a bit
+// for every tile whose arc accepts. Since arc lists are sorted by tile, the
+// arc for a tile is found by counting the children bits below it.
+//
+// It costs 16 bytes per node, on top of the node's own 4.
+type nodeAccel struct {
+	children []tilemapping.LetterSet
+	accepts  []tilemapping.LetterSet
+}
+
+// buildAccelerationTables builds the tables that make NextNodeIdx,
+// InLetterSet and GetLetterSet O(1). It must be called before the KWG is
+// shared, e.g. at load time with WithAccelerationTables. It fails if an arc
+// list isn't sorted by tile or has a tile too big for a LetterSet.
+func (k *KWG) buildAccelerationTables() error {
+	n := len(k.nodes)
+	a := &nodeAccel{
+		children: make([]tilemapping.LetterSet, n),
+		accepts:  make([]tilemapping.LetterSet, n),
+	}
+	for i := n - 1; i >= 0; i-- {
+		idx := uint32(i)
+		t := k.Tile(idx)
+		if t >= 64 {
+			return fmt.Errorf("tile %d at node %d does not fit in a letter set", t, i)
+		}
+		if !k.IsEnd(idx) {
+			if i+1 >= n {
+				return fmt.Errorf("arc list at node %d runs off the end of the graph", i)
+			}
+			if t >= k.Tile(idx+1) {
+				return fmt.Errorf("arc list at node %d is not sorted by tile", i)
+			}
+			a.children[i] = a.children[i+1]
+			a.accepts[i] = a.accepts[i+1]
+		}
+		a.children[i] |= 1 << t
+		if k.Accepts(idx) {
+			a.accepts[i] |= 1 << t
+		}
+	}
+	k.accel = a
+	return nil
+}
+
+// HasAccelerationTables returns whether the graph was loaded with
+// WithAccelerationTables.
+func (k *KWG) HasAccelerationTables() bool {
+	return k.accel != nil
+}
+
+// nextNodeIdx is NextNodeIdx with the acceleration tables.
+func (a *nodeAccel) nextNodeIdx(k *KWG, nodeIdx uint32, letter tilemapping.MachineLetter) uint32 {
+	children := a.children[nodeIdx]
+	bit := tilemapping.LetterSet(1) << letter
+	if children&bit == 0 {
+		return 0
+	}
+	return k.ArcIndex(nodeIdx + uint32(bits.OnesCount64(uint64(children&(bit-1)))))
+}
@@ -0,0 +1,91 @@
+package kwg
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/config"
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+func TestAccelerationTablesMatchLinearScan(t *testing.T) {
+	is := is.New(t)
+	plain := buildTestKWG(t, "ZZZACCEL", extendTestWords...)
+	fast := buildTestKWG(t, "ZZZACCEL", extendTestWords...)
+	is.NoErr(fast.buildAccelerationTables())
+	is.True(fast.HasAccelerationTables())
+	is.True(!plain.HasAccelerationTables())
+
+	for i := range plain.nodes {
+		idx := uint32(i)
+		is.Equal(fast.GetLetterSet(idx), plain.GetLetterSet(idx))
+		for ml := tilemapping.MachineLetter(0); ml < 64; ml++ {
+			is.Equal(fast.NextNodeIdx(idx, ml), plain.NextNodeIdx(idx, ml))
+			is.Equal(fast.InLetterSet(ml, idx), plain.InLetterSet(ml, idx))
+			is.Equal(fast.InLetterSet(ml.Blank(), idx), plain.InLetterSet(ml.Blank(), idx))
+		}
+	}
+}
+
+func TestAccelerationTablesRejectUnsortedArcs(t *testing.T) {
+	is := is.New(t)
+	k := newKWG([]uint32{
+		KWGNodeIsEndBit | 2,
+		KWGNodeIsEndBit,
+		5<<KWGNodeTileShift | KWGNodeAcceptsBit,
+		3<<KWGNodeTileShift | KWGNodeAcceptsBit | KWGNodeIsEndBit,
+	})
+	is.True(k.buildAccelerationTables() != nil)
+	is.True(!k.HasAccelerationTables())
+}
+
+func TestLoadWithAccelerationTables(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	writeTestLexicon(t, cfg, "ZZZLEX50", ".kwg", "AB", "BA", "ABBA")
+	writeTestLexicon(t, cfg, "ZZZLEX50", ".kbwg", "AB", "BA", "ABBA")
+
+	g, err := GetKWG(cfg, "ZZZLEX50", WithDistribution(fakeDistOne), WithAccelerationTables())
+	is.NoErr(err)
+	is.True(g.HasAccelerationTables())
+	is.True(FindWord(g, "ABBA"))
+	g, err = GetKWG(cfg, "ZZZLEX50", WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	is.True(!g.HasAccelerationTables())
+
+	_, err = GetKBWG(cfg, "ZZZLEX50", WithDistribution(fakeDistOne), WithAccelerationTables())
+	is.True(err != nil)
+}
+
+// benchmarkWalk looks up every prefix of some words, and the letter sets
+// along the way.
+func benchmarkWalk(b *testing.B, opts ...LoadOption) {
+	is := is.New(b)
+	kwg, err := GetKWG(config.DefaultConfig, "CSW21", opts...)
+	is.NoErr(err)
+	var words []tilemapping.MachineWord
+	for _, w := range []string{"RETINAS", "QUIXOTRY", "ZYZZYVAS", "AEROBATICS", "STRENGTHS", "OVERWROUGHT"} {
+		mw, err := tilemapping.ToMachineWord(w, kwg.GetAlphabet())
+		is.NoErr(err)
+		words = append(words, mw)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, w := range words {
+			nodeIdx := kwg.ArcIndex(0)
+			for _, ml := range w {
+				_ = kwg.GetLetterSet(nodeIdx)
+				if nodeIdx = kwg.NextNodeIdx(nodeIdx, ml); nodeIdx == 0 {
+					break
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkNodeLookup(b *testing.B) {
+	b.Run("linear", func(b *testing.B) { benchmarkWalk(b) })
+	b.Run("accel", func(b *testing.B) { benchmarkWalk(b, WithAccelerationTables()) })
+}
@@ -0,0 +1,91 @@
+package kwg
+
+import (
+	"errors"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// Verdict is the overall ruling on a play, as shown by a word judge. It
+// deliberately says nothing about which word was invalid.
+type Verdict string
+
+const (
+	VerdictAcceptable   Verdict = "ACCEPTABLE"
+	VerdictUnacceptable Verdict = "UNACCEPTABLE"
+)
+
+// WordValidity is the adjudication of a single formed word.
+type WordValidity struct {
+	// Word is the word as it was passed in, blank designations included.
+	Word  tilemapping.MachineWord
+	Valid bool
+	// ValidIn lists the names of the lexica that contain the word.
+	ValidIn []string
+}
+
+// Adjudication is the full report on the words formed by a play, from
+// AdjudicateWords. It says which words are invalid, so it is for review
+// after a game; during a tournament, use Adjudicate.
+type Adjudication struct {
+	Words []WordValidity
+	Valid bool
+}
+
+// Verdict returns the judge-facing ruling on the play.
+func (a *Adjudication) Verdict() Verdict {
+	if a.Valid {
+		return VerdictAcceptable
+	}
+	return VerdictUnacceptable
+}
+
+// InvalidWords returns the words that were not found in any lexicon, in the
+// order they were passed in.
+func (a *Adjudication) InvalidWords() []tilemapping.MachineWord {
+	invalid := []tilemapping.MachineWord{}
+	for _, w := range a.Words {
+		if !w.Valid {
+			invalid = append(invalid, w.Word)
+		}
+	}
+	return invalid
+}
+
+// Adjudicate rules on a play, the way a word judge does: the play is
+// acceptable if every word it formed is in at least one of the lexica.
+// Blank designations are ignored when looking words up.
+func Adjudicate(words []tilemapping.MachineWord, lexica ...WordValidator) (Verdict, error) {
+	adj, err := AdjudicateWords(words, lexica...)
+	if err != nil {
+		return "", err
+	}
+	return adj.Verdict(), nil
+}
+
+// AdjudicateWords checks every word formed by a play, like Adjudicate, and
+// reports on each word: whether it is valid, and which lexica contain it.
+func AdjudicateWords(words []tilemapping.MachineWord, lexica ...WordValidator) (*Adjudication, error) {
+	if len(words) == 0 {
+		return nil, errors.New("no words to adjudicate")
+	}
+	if len(lexica) == 0 {
+		return nil, errors.New("at least one lexicon is required")
+	}
+	adj := &Adjudication{Words: make([]WordValidity, len(words)), Valid: true}
+	for i, w := range words {
+		lookup := unblankedCopy(w)
+		wv := WordValidity{Word: w, ValidIn: []string{}}
+		for _, lex := range lexica {
+			if lex.HasWord(lookup) {
+				wv.ValidIn = append(wv.ValidIn, lex.Name())
+			}
+		}
+		wv.Valid = len(wv.ValidIn) > 0
+		if !wv.Valid {
+			adj.Valid = false
+		}
+		adj.Words[i] = wv
+	}
+	return adj, nil
+}
@@ -0,0 +1,59 @@
+package kwg
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+func TestAdjudicate(t *testing.T) {
+	is := is.New(t)
+	k1 := buildTestKWG(t, "LEXA", "QI", "ZA", "JO")
+	k2 := buildTestKWG(t, "LEXB", "QI", "OK")
+	lexA, lexB := Lexicon{KWG: *k1}, Lexicon{KWG: *k2}
+	alph := k1.GetAlphabet()
+
+	words := []tilemapping.MachineWord{testMW(t, k1, "qI"), testMW(t, k1, "ZA")}
+	verdict, err := Adjudicate(words, lexA)
+	is.NoErr(err)
+	is.Equal(verdict, VerdictAcceptable)
+	adj, err := AdjudicateWords(words, lexA)
+	is.NoErr(err)
+	is.True(adj.Valid)
+	is.Equal(adj.Verdict(), VerdictAcceptable)
+	is.Equal(len(adj.InvalidWords()), 0)
+	// Blank designations are preserved in the report.
+	is.Equal(adj.Words[0].Word.UserVisible(alph), "qI")
+	is.Equal(adj.Words[0].ValidIn, []string{"LEXA"})
+
+	words = append(words, testMW(t, k1, "OK"))
+	verdict, err = Adjudicate(words, lexA)
+	is.NoErr(err)
+	is.Equal(verdict, VerdictUnacceptable)
+	adj, err = AdjudicateWords(words, lexA)
+	is.NoErr(err)
+	is.True(!adj.Valid)
+	is.Equal(adj.Verdict(), VerdictUnacceptable)
+	is.Equal(userVisibleWords(adj.InvalidWords(), alph), []string{"OK"})
+
+	adj, err = AdjudicateWords(words, lexA, lexB)
+	is.NoErr(err)
+	is.True(adj.Valid)
+	is.Equal(adj.Words[0].ValidIn, []string{"LEXA", "LEXB"})
+	is.Equal(adj.Words[1].ValidIn, []string{"LEXA"})
+	is.Equal(adj.Words[2].ValidIn, []string{"LEXB"})
+}
+
+func TestAdjudicateErrors(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "LEXA", "QI")
+
+	_, err := Adjudicate(nil, Lexicon{KWG: *k})
+	is.True(err != nil)
+	_, err = Adjudicate([]tilemapping.MachineWord{testMW(t, k, "QI")})
+	is.True(err != nil)
+	_, err = AdjudicateWords(nil, Lexicon{KWG: *k})
+	is.True(err != nil)
+}
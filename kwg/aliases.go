@@ -0,0 +1,267 @@
+package kwg
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/domino14/word-golib/cache"
+	"github.com/domino14/word-golib/config"
+)
+
+// LexiconAliasesFile is the name of the file in DataPath/lexica that defines
+// lexicon aliases. Every line is a CSV record alias,lexicon; lines starting
+// with # are comments. For example:
+//
+//	# Collins always means the latest edition.
+//	COLLINS,CSW
+//	TWL,NWL2023
+const LexiconAliasesFile = "aliases.csv"
+
+// CacheKeyPrefixLexiconAliases is the cache key prefix of the parsed
+// LexiconAliasesFile of a data path.
+const CacheKeyPrefixLexiconAliases = "lexaliases:"
+
+// maxAliasDepth bounds alias chains, so that a cycle is an error rather
+// than a hang.
+const maxAliasDepth = 16
+
+// registeredAliases holds the aliases registered in code. They are keyed by
+// lowercased alias and take precedence over LexiconAliasesFile.
+var registeredAliases = struct {
+	sync.RWMutex
+	m map[string]string
+}{m: map[string]string{}}
+
+// resolvedNames memoizes ResolveLexiconName, which is called before every
+// cache lookup of the named loaders and would otherwise read the lexica
+// directory each time. RegisterLexiconAlias clears it and bumps generation,
+// so that a resolution that read the aliases before the change isn't
+// stored afterwards.
+var resolvedNames = struct {
+	sync.Mutex
+	m          map[resolvedNameKey]string
+	generation uint64
+}{m: map[resolvedNameKey]string{}}
+
+type resolvedNameKey struct {
+	dataPath, name, ext string
+}
+
+// RegisterLexiconAlias makes alias (case-insensitively) stand for
+// lexiconName, which may itself be an alias or a family (see
+// ResolveLexiconName). Registering an alias to "" removes it.
+func RegisterLexiconAlias(alias, lexiconName string) {
+	registeredAliases.Lock()
+	if lexiconName == "" {
+		delete(registeredAliases.m, strings.ToLower(alias))
+	} else {
+		registeredAliases.m[strings.ToLower(alias)] = lexiconName
+	}
+	registeredAliases.Unlock()
+
+	resolvedNames.Lock()
+	defer resolvedNames.Unlock()
+	clear(resolvedNames.m)
+	resolvedNames.generation++
+}
+
+// ResolveLexiconName returns the lexicon name that name stands for, for
+// lexicon files with the given extension (e.g. ".kwg"):
+//
+//   - Aliases, registered with RegisterLexiconAlias or defined in
+//     LexiconAliasesFile, are replaced by what they stand for, repeatedly.
+//   - A name that doesn't end in a digit and has no file of its own is a
+//     family, and stands for its latest edition: the lexicon file named
+//     family + edition number with the highest edition. Two-digit editions
+//     are years of this century, so NWL2023 is newer than NWL20.
+//   - Any other name stands for itself.
+//
+// GetKWG and the other named loaders resolve names with it, so that
+// LexiconName reports the resolved name, and "CSW" and "CSW24" share one
+// cached graph when CSW24 is the latest edition.
+//
+// Names that resolve to a lexicon file are memoized per data path and
+// extension, until the next RegisterLexiconAlias: a newer edition that
+// appears later, on disk or with cache.Precache, isn't picked up by a
+// family that was already resolved.
+func ResolveLexiconName(cfg *config.Config, name, ext string) (string, error) {
+	key := resolvedNameKey{cfg.DataPath, name, ext}
+	resolvedNames.Lock()
+	resolved, ok := resolvedNames.m[key]
+	generation := resolvedNames.generation
+	resolvedNames.Unlock()
+	if ok {
+		return resolved, nil
+	}
+	resolved, found, err := resolveLexiconName(cfg, name, ext)
+	if err != nil {
+		return "", err
+	}
+	if found {
+		resolvedNames.Lock()
+		if resolvedNames.generation == generation {
+			resolvedNames.m[key] = resolved
+		}
+		resolvedNames.Unlock()
+	}
+	return resolved, nil
+}
+
+// resolveLexiconName resolves name like ResolveLexiconName, and also
+// returns whether it found a lexicon file for it.
+func resolveLexiconName(cfg *config.Config, name, ext string) (string, bool, error) {
+	fileAliases, err := loadLexiconAliases(cfg)
+	if err != nil {
+		return "", false, err
+	}
+	seen := map[string]bool{}
+	for depth := 0; ; depth++ {
+		key := strings.ToLower(name)
+		if seen[key] || depth > maxAliasDepth {
+			return "", false, fmt.Errorf("lexicon alias %q is circular", name)
+		}
+		seen[key] = true
+		registeredAliases.RLock()
+		target, ok := registeredAliases.m[key]
+		registeredAliases.RUnlock()
+		if !ok {
+			target, ok = fileAliases[key]
+		}
+		if !ok {
+			break
+		}
+		name = target
+	}
+	if lexiconFileExists(cfg, name, ext) {
+		return name, true, nil
+	}
+	if name == "" || isDigit(name[len(name)-1]) {
+		return name, false, nil
+	}
+	if latest := latestEdition(cfg, name, ext); latest != "" {
+		return latest, true, nil
+	}
+	// Let loading the file report that it doesn't exist.
+	return name, false, nil
+}
+
+// lexiconFileExists returns whether the named lexicon file, or its
+// gzip-compressed variant, exists on disk or in the cache.
+func lexiconFileExists(cfg *config.Config, lexiconName, ext string) bool {
+	path := lexiconFilePath(cfg, lexiconName, ext)
+	for _, p := range []string{path, path + gzipExt} {
+		if file, _, err := cache.Open(p); err == nil {
+			file.Close()
+			return true
+		}
+	}
+	return false
+}
+
+// loadLexiconAliases returns the aliases defined in LexiconAliasesFile,
+// keyed by lowercased alias. A missing file defines none.
+func loadLexiconAliases(cfg *config.Config) (map[string]string, error) {
+	if cfg.DataPath == "" {
+		return nil, nil
+	}
+	obj, err := cache.Load(cfg, CacheKeyPrefixLexiconAliases+cfg.DataPath,
+		func(cfg *config.Config, _ string) (interface{}, error) {
+			file, _, err := cache.Open(filepath.Join(cfg.DataPath, "lexica", LexiconAliasesFile))
+			if errors.Is(err, os.ErrNotExist) {
+				return map[string]string{}, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			return ScanLexiconAliases(file)
+		})
+	if err != nil {
+		return nil, err
+	}
+	aliases, ok := obj.(map[string]string)
+	if !ok {
+		return nil, errors.New("could not convert cached object to lexicon aliases")
+	}
+	return aliases, nil
+}
+
+// ScanLexiconAliases reads aliases in the format of LexiconAliasesFile,
+// keyed by lowercased alias.
+func ScanLexiconAliases(data io.Reader) (map[string]string, error) {
+	r := csv.NewReader(data)
+	r.Comment = '#'
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+	aliases := map[string]string{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		alias, lexiconName := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if alias == "" || lexiconName == "" {
+			return nil, fmt.Errorf("empty lexicon alias %q,%q", record[0], record[1])
+		}
+		aliases[strings.ToLower(alias)] = lexiconName
+	}
+	return aliases, nil
+}
+
+// latestEdition returns the name of the latest edition of a family of
+// lexica on disk or precached with cache.Precache, or "" if there is none.
+func latestEdition(cfg *config.Config, family, ext string) string {
+	dir := filepath.Dir(lexiconFilePath(cfg, family, ext))
+	var files []string
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, e := range entries {
+			files = append(files, e.Name())
+		}
+	}
+	for _, key := range cache.GlobalObjectCache.Keys() {
+		if path, ok := strings.CutPrefix(key, cacheKeyPrefixFile); ok && filepath.Dir(path) == dir {
+			files = append(files, filepath.Base(path))
+		}
+	}
+
+	best, bestEdition := "", -1
+	for _, file := range files {
+		name, ok := strings.CutSuffix(strings.TrimSuffix(file, gzipExt), ext)
+		if !ok {
+			continue
+		}
+		if len(name) <= len(family) || !strings.EqualFold(name[:len(family)], family) {
+			continue
+		}
+		edition, err := strconv.Atoi(name[len(family):])
+		if err != nil || name[len(family)] == '-' || name[len(family)] == '+' {
+			continue
+		}
+		if edition < 100 {
+			edition += 2000
+		}
+		// Ties (NWL23 and NWL2023) go to the later name, to be deterministic.
+		if edition > bestEdition || edition == bestEdition && name > best {
+			best, bestEdition = name, edition
+		}
+	}
+	return best
+}
+
+// cacheKeyPrefixFile is the cache key prefix of files added with
+// cache.Precache.
+const cacheKeyPrefixFile = "file:"
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
@@ -0,0 +1,168 @@
+package kwg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/cache"
+)
+
+func TestScanLexiconAliases(t *testing.T) {
+	is := is.New(t)
+	aliases, err := ScanLexiconAliases(strings.NewReader("# comment\nCollins, CSW\nTWL,NWL2023\n"))
+	is.NoErr(err)
+	is.Equal(aliases, map[string]string{"collins": "CSW", "twl": "NWL2023"})
+
+	_, err = ScanLexiconAliases(strings.NewReader("CSW\n"))
+	is.True(err != nil)
+	_, err = ScanLexiconAliases(strings.NewReader("CSW,\n"))
+	is.True(err != nil)
+}
+
+func TestResolveLexiconNameLatestEdition(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	writeFakeLexFile(t, cfg, "ZZZFAM19", ".kwg")
+	writeFakeLexFile(t, cfg, "ZZZFAM2021", ".kwg")
+	writeFakeLexFile(t, cfg, "ZZZFAM24", ".kwg.gz")
+	writeFakeLexFile(t, cfg, "ZZZFAM30", ".kbwg")
+	writeFakeLexFile(t, cfg, "ZZZFAMILY", ".kwg")
+
+	for _, c := range []struct{ name, ext, want string }{
+		{"ZZZFAM", ".kwg", "ZZZFAM24"},
+		{"zzzfam", ".kwg", "ZZZFAM24"},
+		{"ZZZFAM", ".kbwg", "ZZZFAM30"},
+		{"ZZZFAM19", ".kwg", "ZZZFAM19"},
+		// A name with a file of its own isn't a family.
+		{"ZZZFAMILY", ".kwg", "ZZZFAMILY"},
+		{"ZZZNOFAM", ".kwg", "ZZZNOFAM"},
+	} {
+		got, err := ResolveLexiconName(cfg, c.name, c.ext)
+		is.NoErr(err)
+		is.Equal(got, c.want)
+	}
+}
+
+func TestResolveLexiconNameAliases(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	writeFakeLexFile(t, cfg, "ZZZCOL19", ".kwg")
+	writeFakeLexFile(t, cfg, "ZZZCOL21", ".kwg")
+	is.NoErr(os.WriteFile(filepath.Join(cfg.DataPath, "lexica", LexiconAliasesFile),
+		[]byte("zzzcollins,ZZZCOL\nZZZOLD,ZZZCOL19\nZZZLOOP1,ZZZLOOP2\nZZZLOOP2,zzzloop1\n"), 0644))
+
+	got, err := ResolveLexiconName(cfg, "ZZZCollins", ".kwg")
+	is.NoErr(err)
+	is.Equal(got, "ZZZCOL21")
+	got, err = ResolveLexiconName(cfg, "ZZZOLD", ".kwg")
+	is.NoErr(err)
+	is.Equal(got, "ZZZCOL19")
+	_, err = ResolveLexiconName(cfg, "ZZZLOOP1", ".kwg")
+	is.True(err != nil)
+
+	// Aliases registered in code take precedence.
+	RegisterLexiconAlias("ZZZOLD", "zzzcollins")
+	defer RegisterLexiconAlias("ZZZOLD", "")
+	got, err = ResolveLexiconName(cfg, "ZZZOLD", ".kwg")
+	is.NoErr(err)
+	is.Equal(got, "ZZZCOL21")
+}
+
+func TestGetKWGResolvesAliases(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	writeFakeDist(t, cfg, fakeDistOne, fakeDistOneContent)
+	writeFakeLexFile(t, cfg, "ZZZGETFAM18", ".kwg")
+	writeFakeLexFile(t, cfg, "ZZZGETFAM22", ".kwg")
+	RegisterLexiconAlias("ZZZGETALIAS", "ZZZGETFAM")
+	defer RegisterLexiconAlias("ZZZGETALIAS", "")
+
+	k, err := GetKWG(cfg, "ZZZGETALIAS", WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	is.Equal(k.LexiconName(), "ZZZGETFAM22")
+	// The alias, the family and the resolved name share one cached graph.
+	for _, name := range []string{"ZZZGETFAM", "ZZZGETFAM22"} {
+		k2, err := GetKWG(cfg, name, WithDistribution(fakeDistOne))
+		is.NoErr(err)
+		is.True(k2 == k)
+	}
+}
+
+func TestResolveLexiconNameIsMemoized(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	path := writeFakeLexFile(t, cfg, "ZZZMEMO20", ".kwg")
+
+	got, err := ResolveLexiconName(cfg, "ZZZMEMO", ".kwg")
+	is.NoErr(err)
+	is.Equal(got, "ZZZMEMO20")
+	// A resolved name doesn't look at the files again...
+	is.NoErr(os.Remove(path))
+	writeFakeLexFile(t, cfg, "ZZZMEMO21", ".kwg")
+	got, err = ResolveLexiconName(cfg, "ZZZMEMO", ".kwg")
+	is.NoErr(err)
+	is.Equal(got, "ZZZMEMO20")
+	// ...but one without a file does, so that it can be added later.
+	got, err = ResolveLexiconName(cfg, "ZZZMEMOX", ".kwg")
+	is.NoErr(err)
+	is.Equal(got, "ZZZMEMOX")
+	writeFakeLexFile(t, cfg, "ZZZMEMOX2", ".kwg")
+	got, err = ResolveLexiconName(cfg, "ZZZMEMOX", ".kwg")
+	is.NoErr(err)
+	is.Equal(got, "ZZZMEMOX2")
+}
+
+func TestResolveLexiconNamePrecachedEdition(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	writeFakeLexFile(t, cfg, "ZZZPRE19", ".kwg")
+	cache.Precache(lexiconFilePath(cfg, "ZZZPRE23", ".kwg"), fakeGraphNodes)
+
+	got, err := ResolveLexiconName(cfg, "ZZZPRE", ".kwg")
+	is.NoErr(err)
+	is.Equal(got, "ZZZPRE23")
+}
+
+func TestRegisterLexiconAliasDuringResolve(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	writeFakeLexFile(t, cfg, "ZZZGENA1", ".kwg")
+	writeFakeLexFile(t, cfg, "ZZZGENB1", ".kwg")
+	defer RegisterLexiconAlias("ZZZGEN", "")
+
+	for range 5 {
+		// Change the alias while it is being resolved.
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						ResolveLexiconName(cfg, "ZZZGEN", ".kwg")
+					}
+				}
+			}()
+		}
+		for _, target := range []string{"ZZZGENA", "ZZZGENB", "ZZZGENA", "ZZZGENB"} {
+			time.Sleep(100 * time.Microsecond)
+			RegisterLexiconAlias("ZZZGEN", target)
+		}
+		close(stop)
+		wg.Wait()
+		// Resolutions that read the old alias weren't memoized.
+		got, err := ResolveLexiconName(cfg, "ZZZGEN", ".kwg")
+		is.NoErr(err)
+		is.Equal(got, "ZZZGENB1")
+	}
+}
@@ -0,0 +1,433 @@
+package kwg
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/domino14/word-golib/cache"
+	"github.com/domino14/word-golib/config"
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// AttributeTableExt is the file extension of attribute tables. The table
+// named defs of lexicon CSW21 lives next to the KWGs, as CSW21.defs.attr.
+const AttributeTableExt = ".attr"
+
+// CacheKeyPrefixAttributes is the cache key prefix of attribute tables.
+const CacheKeyPrefixAttributes = "attr:"
+
+// attributeMagic starts every attribute table file.
+const attributeMagic = "WATR"
+
+const attributeVersion = 1
+
+// attributeWordBuf is the longest word WordIndex looks up without
+// allocating.
+const attributeWordBuf = 32
+
+// AttributeType is the type of the values in an attribute column.
+type AttributeType uint8
+
+const (
+	// AttributeString columns hold text, e.g. definitions.
+	AttributeString AttributeType = iota + 1
+	// AttributeFloat32 columns hold numbers, e.g. playability scores.
+	AttributeFloat32
+	// AttributeFlags columns hold up to 64 named flags per word, e.g. "new
+	// in this edition".
+	AttributeFlags
+)
+
+func (t AttributeType) String() string {
+	switch t {
+	case AttributeString:
+		return "string"
+	case AttributeFloat32:
+		return "float32"
+	case AttributeFlags:
+		return "flags"
+	}
+	return fmt.Sprintf("AttributeType(%d)", int(t))
+}
+
+// AttributeColumn describes a column of an attribute table.
+type AttributeColumn struct {
+	Name string
+	Type AttributeType
+	// Flags names the bits of an AttributeFlags column, from bit 0 up.
+	Flags []string
+}
+
+// attributeData holds the values of a column, one per word, in the slice
+// that matches the column's type. The strings of a string column are
+// stored back to back in text; word i's is text[offsets[i]:offsets[i+1]].
+type attributeData struct {
+	offsets []uint32
+	text    string
+	floats  []float32
+	flags   []uint64
+}
+
+// An AttributeTable holds per-word attributes of a lexicon, such as
+// definitions, playability scores and flags, in typed columns. Values are
+// stored by word index (see KWG.GetWordIndexOf), so a table only works with
+// the lexicon it was built for, which is checked with its Fingerprint.
+// Words without a value have the zero value of their column's type.
+//
+// File layout (all integers little-endian):
+//
+//	"WATR", u32 version, 32-byte SHA-256 lexicon fingerprint,
+//	u32 number of words, u32 number of columns, then for each column:
+//	    u8 type, its name, u8 number of flags and the flag names (names
+//	    are a u16 length and UTF-8 bytes), then its values:
+//	    string:  u32 number of bytes of text, the text, then one u32 end
+//	             offset into it per word
+//	    float32: one float32 per word
+//	    flags:   one u64 per word
+type AttributeTable struct {
+	fingerprint string
+	numWords    int
+	columns     []AttributeColumn
+	data        []attributeData
+	kwg         *KWG
+}
+
+// Fingerprint returns the fingerprint of the lexicon the table is for.
+func (t *AttributeTable) Fingerprint() string {
+	return t.fingerprint
+}
+
+// NumWords returns the number of words in the table's lexicon.
+func (t *AttributeTable) NumWords() int {
+	return t.numWords
+}
+
+// Columns returns the table's columns. It must not be modified.
+func (t *AttributeTable) Columns() []AttributeColumn {
+	return t.columns
+}
+
+// ColumnIndex returns the index of the named column, or -1.
+func (t *AttributeTable) ColumnIndex(name string) int {
+	for i, c := range t.columns {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// FlagMask returns the bit of the named flag of a flags column, or 0.
+func (t *AttributeTable) FlagMask(col int, flag string) uint64 {
+	for i, f := range t.columns[col].Flags {
+		if f == flag {
+			return 1 << i
+		}
+	}
+	return 0
+}
+
+// Bind attaches the table to the KWG of its lexicon, for the lookups by
+// word. It fails with a *FingerprintMismatchError if kwg has another
+// lexicon, and fails if the table doesn't have a row for every word of it.
+// It must be called before the table is shared.
+func (t *AttributeTable) Bind(kwg *KWG) error {
+	if fp := kwg.Fingerprint(); fp != t.fingerprint {
+		return &FingerprintMismatchError{
+			LexiconName: kwg.LexiconName(),
+			Expected:    t.fingerprint,
+			Actual:      fp,
+		}
+	}
+	if n := dawgWordCount(kwg); n != t.numWords {
+		return fmt.Errorf("attribute table has %d words, lexicon %s has %d",
+			t.numWords, kwg.LexiconName(), n)
+	}
+	t.kwg = kwg
+	return nil
+}
+
+// dawgWordCount returns the number of words in the DAWG of kwg, counting
+// them if needed.
+func dawgWordCount(kwg *KWG) int {
+	// An empty DAWG has no root node.
+	root := kwg.ArcIndex(0)
+	if root == 0 {
+		return 0
+	}
+	return int(kwg.WordCountAt(root))
+}
+
+// WordIndex returns the word index of word in the table's lexicon, or -1 if
+// it isn't a word. Designated blanks are looked up as their letters. The
+// table must be bound to a KWG.
+func (t *AttributeTable) WordIndex(word tilemapping.MachineWord) int {
+	if t.kwg == nil || len(word) == 0 {
+		return -1
+	}
+	var buf [attributeWordBuf]tilemapping.MachineLetter
+	var unblanked tilemapping.MachineWord
+	if len(word) <= attributeWordBuf {
+		unblanked = buf[:len(word)]
+	} else {
+		unblanked = make(tilemapping.MachineWord, len(word))
+	}
+	for i, ml := range word {
+		unblanked[i] = ml.Unblank()
+	}
+	idx := t.kwg.GetWordIndexOf(t.kwg.ArcIndex(0), unblanked)
+	if idx < 0 || int(idx) >= t.numWords {
+		return -1
+	}
+	return int(idx)
+}
+
+func (t *AttributeTable) checkColumn(col int, typ AttributeType) {
+	if t.columns[col].Type != typ {
+		panic(fmt.Sprintf("attribute column %q is %v, not %v", t.columns[col].Name, t.columns[col].Type, typ))
+	}
+}
+
+// StringAt returns the value of a string column for the word with the given
+// index.
+func (t *AttributeTable) StringAt(col, wordIdx int) string {
+	t.checkColumn(col, AttributeString)
+	d := &t.data[col]
+	return d.text[d.offsets[wordIdx]:d.offsets[wordIdx+1]]
+}
+
+// Float32At returns the value of a float32 column for the word with the
+// given index.
+func (t *AttributeTable) Float32At(col, wordIdx int) float32 {
+	t.checkColumn(col, AttributeFloat32)
+	return t.data[col].floats[wordIdx]
+}
+
+// FlagsAt returns the value of a flags column for the word with the given
+// index.
+func (t *AttributeTable) FlagsAt(col, wordIdx int) uint64 {
+	t.checkColumn(col, AttributeFlags)
+	return t.data[col].flags[wordIdx]
+}
+
+// LookupString returns the value of a string column for word, and whether word is
+// in the lexicon. It panics if the column isn't a string column.
+func (t *AttributeTable) LookupString(col int, word tilemapping.MachineWord) (string, bool) {
+	idx := t.WordIndex(word)
+	if idx < 0 {
+		return "", false
+	}
+	return t.StringAt(col, idx), true
+}
+
+// LookupFloat32 returns the value of a float32 column for word, and whether word
+// is in the lexicon. It panics if the column isn't a float32 column.
+func (t *AttributeTable) LookupFloat32(col int, word tilemapping.MachineWord) (float32, bool) {
+	idx := t.WordIndex(word)
+	if idx < 0 {
+		return 0, false
+	}
+	return t.Float32At(col, idx), true
+}
+
+// LookupFlags returns the value of a flags column for word, and whether word is
+// in the lexicon. It panics if the column isn't a flags column.
+func (t *AttributeTable) LookupFlags(col int, word tilemapping.MachineWord) (uint64, bool) {
+	idx := t.WordIndex(word)
+	if idx < 0 {
+		return 0, false
+	}
+	return t.FlagsAt(col, idx), true
+}
+
+// attributeReader reads the parts of an attribute table file, checking
+// lengths against the number of bytes left so that a corrupt file can't
+// cause huge allocations.
+type attributeReader struct {
+	r    io.Reader
+	left int
+}
+
+func (ar *attributeReader) read(v any) error {
+	n := binary.Size(v)
+	if n > ar.left {
+		return errors.New("attribute table file is truncated")
+	}
+	ar.left -= n
+	return binary.Read(ar.r, binary.LittleEndian, v)
+}
+
+func (ar *attributeReader) bytes(n int) ([]byte, error) {
+	if n > ar.left {
+		return nil, errors.New("attribute table file is truncated")
+	}
+	ar.left -= n
+	b := make([]byte, n)
+	_, err := io.ReadFull(ar.r, b)
+	return b, err
+}
+
+func (ar *attributeReader) name() (string, error) {
+	var n uint16
+	if err := ar.read(&n); err != nil {
+		return "", err
+	}
+	b, err := ar.bytes(int(n))
+	return string(b), err
+}
+
+// ScanAttributeTable reads an attribute table. It must be bound to its
+// lexicon's KWG with Bind before words can be looked up.
+func ScanAttributeTable(data io.Reader, filesize int) (*AttributeTable, error) {
+	ar := &attributeReader{r: data, left: filesize}
+	magic, err := ar.bytes(len(attributeMagic))
+	if err != nil {
+		return nil, err
+	}
+	if string(magic) != attributeMagic {
+		return nil, errors.New("not an attribute table file")
+	}
+	var header struct {
+		Version     uint32
+		Fingerprint [32]byte
+		NumWords    uint32
+		NumColumns  uint32
+	}
+	if err := ar.read(&header); err != nil {
+		return nil, err
+	}
+	if header.Version != attributeVersion {
+		return nil, fmt.Errorf("unsupported attribute table version %d", header.Version)
+	}
+	t := &AttributeTable{
+		fingerprint: hex.EncodeToString(header.Fingerprint[:]),
+		numWords:    int(header.NumWords),
+	}
+	for range header.NumColumns {
+		var typ uint8
+		if err := ar.read(&typ); err != nil {
+			return nil, err
+		}
+		col := AttributeColumn{Type: AttributeType(typ)}
+		switch col.Type {
+		case AttributeString, AttributeFloat32, AttributeFlags:
+		default:
+			return nil, fmt.Errorf("unknown attribute type %v", col.Type)
+		}
+		if col.Name, err = ar.name(); err != nil {
+			return nil, err
+		}
+		var numFlags uint8
+		if err := ar.read(&numFlags); err != nil {
+			return nil, err
+		}
+		if numFlags > 64 {
+			return nil, fmt.Errorf("attribute column %q has more than 64 flags", col.Name)
+		}
+		for range numFlags {
+			flag, err := ar.name()
+			if err != nil {
+				return nil, err
+			}
+			col.Flags = append(col.Flags, flag)
+		}
+		d, err := ar.columnData(col.Type, t.numWords)
+		if err != nil {
+			return nil, fmt.Errorf("attribute column %q: %w", col.Name, err)
+		}
+		t.columns = append(t.columns, col)
+		t.data = append(t.data, d)
+	}
+	if err := validateAttributeColumns(t.columns); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (ar *attributeReader) columnData(typ AttributeType, numWords int) (attributeData, error) {
+	var d attributeData
+	if numWords > ar.left {
+		return d, errors.New("attribute table file is truncated")
+	}
+	switch typ {
+	case AttributeString:
+		var n uint32
+		if err := ar.read(&n); err != nil {
+			return d, err
+		}
+		text, err := ar.bytes(int(n))
+		if err != nil {
+			return d, err
+		}
+		d.text = string(text)
+		d.offsets = make([]uint32, numWords+1)
+		if err := ar.read(d.offsets[1:]); err != nil {
+			return d, err
+		}
+		for i := 1; i <= numWords; i++ {
+			if d.offsets[i] < d.offsets[i-1] || d.offsets[i] > n {
+				return d, errors.New("string offsets are out of range")
+			}
+		}
+	case AttributeFloat32:
+		d.floats = make([]float32, numWords)
+		if err := ar.read(d.floats); err != nil {
+			return d, err
+		}
+	case AttributeFlags:
+		d.flags = make([]uint64, numWords)
+		if err := ar.read(d.flags); err != nil {
+			return d, err
+		}
+	default:
+		return d, fmt.Errorf("unknown attribute type %v", typ)
+	}
+	return d, nil
+}
+
+// LoadAttributeTable loads an attribute table from a file, and binds it to
+// kwg.
+func LoadAttributeTable(filename string, kwg *KWG) (*AttributeTable, error) {
+	log.Debug().Msgf("Loading %v ...", filename)
+	file, filesize, err := cache.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	t, err := ScanAttributeTable(file, filesize)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Bind(kwg); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetAttributeTable loads the named attribute table of a lexicon from the
+// cache or from a file, bound to the lexicon's KWG as loaded by GetKWG with
+// the given options. The lexicon name may be an alias.
+func GetAttributeTable(cfg *config.Config, lexiconName, tableName string, opts ...LoadOption) (*AttributeTable, error) {
+	kwg, err := GetKWG(cfg, lexiconName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	name := kwg.LexiconName() + "." + tableName
+	key := resolveLoadOpts(opts).cacheKey(CacheKeyPrefixAttributes, name)
+	obj, err := cache.Load(cfg, key, func(cfg *config.Config, _ string) (interface{}, error) {
+		return LoadAttributeTable(lexiconFilePath(cfg, name, AttributeTableExt), kwg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	t, ok := obj.(*AttributeTable)
+	if !ok {
+		return nil, errors.New("could not convert cached object to attribute table")
+	}
+	return t, nil
+}
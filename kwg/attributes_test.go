@@ -0,0 +1,79 @@
+package kwg
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestAttributeTableBindChecksLexicon(t *testing.T) {
+	is := is.New(t)
+	_, table := buildAttributeTestTable(t)
+	other := buildTestKWG(t, "ZZZATTR", "CARE", "ACRE", "RACE", "CAR", "ARC", "ARE")
+	var mismatch *FingerprintMismatchError
+	is.True(errors.As(table.Bind(other), &mismatch))
+	is.Equal(mismatch.Expected, table.Fingerprint())
+}
+
+func TestAttributeTableBindChecksWordCount(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "ZZZATTR", "CARE", "ACRE", "RACE")
+	table, err := BuildAttributeTable(k, nil, bytes.NewReader(nil))
+	is.NoErr(err)
+	buf := &bytes.Buffer{}
+	is.NoErr(table.Save(buf))
+	// A table of the right lexicon, but with a row too many.
+	data := buf.Bytes()
+	data[len(attributeMagic)+4+32]++
+	loaded, err := ScanAttributeTable(bytes.NewReader(data), len(data))
+	is.NoErr(err)
+	is.True(loaded.Bind(k) != nil)
+
+	empty := buildTestKWG(t, "ZZZATTR")
+	table, err = BuildAttributeTable(empty, nil, bytes.NewReader(nil))
+	is.NoErr(err)
+	is.Equal(table.NumWords(), 0)
+	is.NoErr(table.Bind(empty))
+}
+
+func TestAttributeTableWrongColumnTypePanics(t *testing.T) {
+	is := is.New(t)
+	_, table := buildAttributeTestTable(t)
+	defer func() {
+		is.True(recover() != nil)
+	}()
+	table.Float32At(table.ColumnIndex("def"), 0)
+}
+
+func TestGetAttributeTable(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	k := writeTestLexicon(t, cfg, "ZZZLEX70", ".kwg", "AB", "BA", "ABBA")
+
+	table, err := BuildAttributeTable(k, []AttributeColumn{{Name: "score", Type: AttributeFloat32}},
+		bytes.NewReader([]byte("ABBA\t3.5\n")))
+	is.NoErr(err)
+	buf := &bytes.Buffer{}
+	is.NoErr(table.Save(buf))
+	is.NoErr(os.WriteFile(lexiconFilePath(cfg, "ZZZLEX70.play", AttributeTableExt), buf.Bytes(), 0644))
+
+	RegisterLexiconAlias("ZZZALIAS70", "ZZZLEX70")
+	defer RegisterLexiconAlias("ZZZALIAS70", "")
+	got, err := GetAttributeTable(cfg, "ZZZALIAS70", "play", WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	g, err := GetKWG(cfg, "ZZZLEX70", WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	f, ok := got.LookupFloat32(0, testMW(t, g, "ABBA"))
+	is.True(ok)
+	is.Equal(f, float32(3.5))
+
+	again, err := GetAttributeTable(cfg, "ZZZLEX70", "play", WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	is.True(again == got)
+
+	_, err = GetAttributeTable(cfg, "ZZZLEX70", "defs", WithDistribution(fakeDistOne))
+	is.True(err != nil)
+}
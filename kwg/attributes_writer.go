@@ -0,0 +1,220 @@
+package kwg
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// maxAttributeLine is the longest line BuildAttributeTable reads.
+const maxAttributeLine = 1 << 20
+
+// BuildAttributeTable builds an attribute table for the lexicon of kwg from
+// tab-separated lines of a word followed by one value per column:
+//
+//	CARE	to be concerned	0.82	common,new
+//
+// Float32 values are decimal numbers, and flags values are comma-separated
+// flag names. Empty and missing trailing values are zero. Lines that are
+// empty or start with # are skipped. Every word must be in the lexicon, and
+// can only be listed once.
+func BuildAttributeTable(kwg *KWG, columns []AttributeColumn, r io.Reader) (*AttributeTable, error) {
+	if err := validateAttributeColumns(columns); err != nil {
+		return nil, err
+	}
+	fingerprint := kwg.Fingerprint()
+	if fingerprint == "" {
+		return nil, errors.New("word graph has no alphabet")
+	}
+	numWords := dawgWordCount(kwg)
+	t := &AttributeTable{
+		fingerprint: fingerprint,
+		numWords:    numWords,
+		columns:     append([]AttributeColumn(nil), columns...),
+		data:        make([]attributeData, len(columns)),
+		kwg:         kwg,
+	}
+	stringValues := make([][]string, len(columns))
+	for i, c := range columns {
+		switch c.Type {
+		case AttributeString:
+			stringValues[i] = make([]string, numWords)
+		case AttributeFloat32:
+			t.data[i].floats = make([]float32, numWords)
+		case AttributeFlags:
+			t.data[i].flags = make([]uint64, numWords)
+		}
+	}
+
+	seen := make([]bool, numWords)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxAttributeLine)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) > len(columns)+1 {
+			return nil, fmt.Errorf("line %d: %d values for %d columns", lineNum, len(fields)-1, len(columns))
+		}
+		mw, err := tilemapping.ToMachineWord(fields[0], kwg.GetAlphabet())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		idx := t.WordIndex(mw)
+		if idx < 0 {
+			return nil, fmt.Errorf("line %d: %q is not in the lexicon", lineNum, fields[0])
+		}
+		if seen[idx] {
+			return nil, fmt.Errorf("line %d: %q is listed more than once", lineNum, fields[0])
+		}
+		seen[idx] = true
+		for i, v := range fields[1:] {
+			if v == "" {
+				continue
+			}
+			switch columns[i].Type {
+			case AttributeString:
+				stringValues[i][idx] = v
+			case AttributeFloat32:
+				f, err := strconv.ParseFloat(v, 32)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: column %q: %w", lineNum, columns[i].Name, err)
+				}
+				t.data[i].floats[idx] = float32(f)
+			case AttributeFlags:
+				flags, err := parseAttributeFlags(columns[i], v)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNum, err)
+				}
+				t.data[i].flags[idx] = flags
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, values := range stringValues {
+		if values == nil {
+			continue
+		}
+		var sb strings.Builder
+		offsets := make([]uint32, numWords+1)
+		for j, v := range values {
+			sb.WriteString(v)
+			if uint64(sb.Len()) > math.MaxUint32 {
+				return nil, fmt.Errorf("column %q has too much text", columns[i].Name)
+			}
+			offsets[j+1] = uint32(sb.Len())
+		}
+		t.data[i].text = sb.String()
+		t.data[i].offsets = offsets
+	}
+	return t, nil
+}
+
+func validateAttributeColumns(columns []AttributeColumn) error {
+	names := map[string]bool{}
+	for _, c := range columns {
+		if c.Name == "" || len(c.Name) > math.MaxUint16 || names[c.Name] {
+			return fmt.Errorf("attribute column name %q is empty, too long or not unique", c.Name)
+		}
+		names[c.Name] = true
+		switch c.Type {
+		case AttributeString, AttributeFloat32:
+			if len(c.Flags) > 0 {
+				return fmt.Errorf("attribute column %q is %v, it can't have flags", c.Name, c.Type)
+			}
+		case AttributeFlags:
+			if len(c.Flags) > 64 {
+				return fmt.Errorf("attribute column %q has more than 64 flags", c.Name)
+			}
+			flags := map[string]bool{}
+			for _, f := range c.Flags {
+				if f == "" || len(f) > math.MaxUint16 || strings.ContainsAny(f, ",\t") || flags[f] {
+					return fmt.Errorf("flag %q of attribute column %q is invalid or not unique", f, c.Name)
+				}
+				flags[f] = true
+			}
+		default:
+			return fmt.Errorf("attribute column %q has unknown type %v", c.Name, c.Type)
+		}
+	}
+	return nil
+}
+
+func parseAttributeFlags(c AttributeColumn, v string) (uint64, error) {
+	var flags uint64
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+		bit := -1
+		for i, f := range c.Flags {
+			if f == name {
+				bit = i
+				break
+			}
+		}
+		if bit < 0 {
+			return 0, fmt.Errorf("column %q has no flag %q", c.Name, name)
+		}
+		flags |= 1 << bit
+	}
+	return flags, nil
+}
+
+// Save writes the attribute table to w, in the format read by
+// ScanAttributeTable.
+func (t *AttributeTable) Save(w io.Writer) error {
+	fingerprint, err := hex.DecodeString(t.fingerprint)
+	if err != nil || len(fingerprint) != 32 {
+		return errors.New("attribute table has an invalid fingerprint")
+	}
+	bw := bufio.NewWriter(w)
+	write := func(v any) {
+		if err == nil {
+			err = binary.Write(bw, binary.LittleEndian, v)
+		}
+	}
+	writeName := func(name string) {
+		write(uint16(len(name)))
+		write([]byte(name))
+	}
+	write([]byte(attributeMagic))
+	write(uint32(attributeVersion))
+	write(fingerprint)
+	write(uint32(t.numWords))
+	write(uint32(len(t.columns)))
+	for i, c := range t.columns {
+		write(uint8(c.Type))
+		writeName(c.Name)
+		write(uint8(len(c.Flags)))
+		for _, f := range c.Flags {
+			writeName(f)
+		}
+		d := &t.data[i]
+		switch c.Type {
+		case AttributeString:
+			write(uint32(len(d.text)))
+			write([]byte(d.text))
+			write(d.offsets[1:])
+		case AttributeFloat32:
+			write(d.floats)
+		case AttributeFlags:
+			write(d.flags)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
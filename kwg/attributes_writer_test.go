@@ -0,0 +1,160 @@
+package kwg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+var attributeTestColumns = []AttributeColumn{
+	{Name: "def", Type: AttributeString},
+	{Name: "playability", Type: AttributeFloat32},
+	{Name: "flags", Type: AttributeFlags, Flags: []string{"new", "common"}},
+}
+
+const attributeTestTSV = `# word	def	playability	flags
+CARE	to be concerned	0.82	common
+ACRE	a unit of area	0.5	new,common
+RACE	a contest
+CAR		1.25
+`
+
+func buildAttributeTestTable(t *testing.T) (*KWG, *AttributeTable) {
+	t.Helper()
+	k := buildTestKWG(t, "ZZZATTR", "CARE", "ACRE", "RACE", "CAR", "ARC")
+	table, err := BuildAttributeTable(k, attributeTestColumns, strings.NewReader(attributeTestTSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k, table
+}
+
+func TestBuildAttributeTable(t *testing.T) {
+	is := is.New(t)
+	k, table := buildAttributeTestTable(t)
+	is.Equal(table.NumWords(), 5)
+	is.Equal(table.Fingerprint(), k.Fingerprint())
+	def, play, flags := table.ColumnIndex("def"), table.ColumnIndex("playability"), table.ColumnIndex("flags")
+	is.Equal(table.ColumnIndex("pos"), -1)
+
+	s, ok := table.LookupString(def, testMW(t, k, "CARE"))
+	is.True(ok)
+	is.Equal(s, "to be concerned")
+	// Designated blanks are looked up as their letters.
+	s, ok = table.LookupString(def, testMW(t, k, "aCRE"))
+	is.True(ok)
+	is.Equal(s, "a unit of area")
+	s, ok = table.LookupString(def, testMW(t, k, "CAR"))
+	is.True(ok)
+	is.Equal(s, "")
+	_, ok = table.LookupString(def, testMW(t, k, "CARES"))
+	is.True(!ok)
+
+	f, ok := table.LookupFloat32(play, testMW(t, k, "CAR"))
+	is.True(ok)
+	is.Equal(f, float32(1.25))
+	f, _ = table.LookupFloat32(play, testMW(t, k, "ARC"))
+	is.Equal(f, float32(0))
+
+	fl, ok := table.LookupFlags(flags, testMW(t, k, "ACRE"))
+	is.True(ok)
+	is.Equal(fl, table.FlagMask(flags, "new")|table.FlagMask(flags, "common"))
+	fl, _ = table.LookupFlags(flags, testMW(t, k, "CARE"))
+	is.Equal(fl, table.FlagMask(flags, "common"))
+	is.Equal(table.FlagMask(flags, "old"), uint64(0))
+}
+
+func TestBuildAttributeTableErrors(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "ZZZATTR", "CARE", "ACRE")
+	for _, tsv := range []string{
+		"CARES\tx\n",
+		"CARE\tx\nCARE\ty\n",
+		"CARE\tx\t1\tnew\textra\n",
+		"CARE\tx\tlots\n",
+		"CARE\tx\t1\told\n",
+	} {
+		_, err := BuildAttributeTable(k, attributeTestColumns, strings.NewReader(tsv))
+		is.True(err != nil)
+	}
+	for _, cols := range [][]AttributeColumn{
+		{{Name: "", Type: AttributeString}},
+		{{Name: "a", Type: AttributeString}, {Name: "a", Type: AttributeFloat32}},
+		{{Name: "a", Type: AttributeString, Flags: []string{"x"}}},
+		{{Name: "a", Type: AttributeFlags, Flags: []string{"x,y"}}},
+		{{Name: "a", Type: AttributeType(9)}},
+	} {
+		_, err := BuildAttributeTable(k, cols, strings.NewReader(""))
+		is.True(err != nil)
+	}
+}
+
+func TestAttributeTableRoundTrip(t *testing.T) {
+	is := is.New(t)
+	k, table := buildAttributeTestTable(t)
+	buf := &bytes.Buffer{}
+	is.NoErr(table.Save(buf))
+
+	loaded, err := ScanAttributeTable(bytes.NewReader(buf.Bytes()), buf.Len())
+	is.NoErr(err)
+	is.Equal(loaded.Columns(), table.Columns())
+	is.Equal(loaded.Fingerprint(), table.Fingerprint())
+	// Words can't be looked up before binding.
+	_, ok := loaded.LookupString(0, testMW(t, k, "CARE"))
+	is.True(!ok)
+	is.NoErr(loaded.Bind(k))
+	for i := range table.NumWords() {
+		is.Equal(loaded.StringAt(0, i), table.StringAt(0, i))
+		is.Equal(loaded.Float32At(1, i), table.Float32At(1, i))
+		is.Equal(loaded.FlagsAt(2, i), table.FlagsAt(2, i))
+	}
+
+	// Truncated files fail instead of panicking.
+	for n := 0; n < buf.Len(); n++ {
+		_, err := ScanAttributeTable(bytes.NewReader(buf.Bytes()[:n]), n)
+		is.True(err != nil)
+	}
+}
+
+func TestScanAttributeTableRejectsBadColumns(t *testing.T) {
+	is := is.New(t)
+	// header returns a table file of no words with one column.
+	header := func(typ AttributeType, flags int) []byte {
+		buf := &bytes.Buffer{}
+		buf.WriteString(attributeMagic)
+		binary.Write(buf, binary.LittleEndian, uint32(attributeVersion))
+		buf.Write(make([]byte, 32))
+		binary.Write(buf, binary.LittleEndian, [2]uint32{0, 1})
+		buf.WriteByte(byte(typ))
+		binary.Write(buf, binary.LittleEndian, uint16(1))
+		buf.WriteString("c")
+		buf.WriteByte(byte(flags))
+		for i := range flags {
+			name := fmt.Sprintf("f%d", i)
+			binary.Write(buf, binary.LittleEndian, uint16(len(name)))
+			buf.WriteString(name)
+		}
+		if typ == AttributeString {
+			binary.Write(buf, binary.LittleEndian, uint32(0))
+		}
+		return buf.Bytes()
+	}
+	for _, c := range []struct {
+		typ   AttributeType
+		flags int
+		ok    bool
+	}{
+		{AttributeFlags, 64, true},
+		{AttributeFlags, 65, false},
+		{AttributeType(9), 0, false},
+		{AttributeString, 1, false},
+	} {
+		data := header(c.typ, c.flags)
+		_, err := ScanAttributeTable(bytes.NewReader(data), len(data))
+		is.Equal(err == nil, c.ok)
+	}
+}
@@ -0,0 +1,150 @@
+package kwg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// builderNode is a trie node used while building a KWG. Its children are
+// kept sorted by tile.
+type builderNode struct {
+	tiles    []uint8
+	children []int32
+	accepts  bool
+}
+
+type kwgBuilder struct {
+	trie []builderNode
+}
+
+func newKWGBuilder() *kwgBuilder {
+	return &kwgBuilder{trie: []builderNode{{}}}
+}
+
+// insert adds a path starting at the trie root rootIdx.
+func (b *kwgBuilder) insert(rootIdx int32, word tilemapping.MachineWord) {
+	n := rootIdx
+	for _, ml := range word {
+		t := uint8(ml)
+		tiles := b.trie[n].tiles
+		i := sort.Search(len(tiles), func(i int) bool { return tiles[i] >= t })
+		if i < len(tiles) && tiles[i] == t {
+			n = b.trie[n].children[i]
+			continue
+		}
+		b.trie = append(b.trie, builderNode{})
+		c := int32(len(b.trie) - 1)
+		node := &b.trie[n]
+		node.tiles = append(node.tiles, 0)
+		copy(node.tiles[i+1:], node.tiles[i:])
+		node.tiles[i] = t
+		node.children = append(node.children, 0)
+		copy(node.children[i+1:], node.children[i:])
+		node.children[i] = c
+		n = c
+	}
+	b.trie[n].accepts = true
+}
+
+func (b *kwgBuilder) newRoot() int32 {
+	b.trie = append(b.trie, builderNode{})
+	return int32(len(b.trie) - 1)
+}
+
+// serializer turns the trie into KWG nodes, sharing identical arc lists.
+type serializer struct {
+	b      *kwgBuilder
+	nodes  []uint32
+	lists  map[string]uint32
+	placed []uint32
+}
+
+// place returns the node index of the arc list of trie node n, or 0 if it
+// has no children.
+func (s *serializer) place(n int32) (uint32, error) {
+	if s.placed[n] != 0 || len(s.b.trie[n].tiles) == 0 {
+		return s.placed[n], nil
+	}
+	node := s.b.trie[n]
+	arcs := make([]uint32, len(node.tiles))
+	for i, t := range node.tiles {
+		child, err := s.place(node.children[i])
+		if err != nil {
+			return 0, err
+		}
+		arcs[i] = uint32(t)<<KWGNodeTileShift | child
+		if s.b.trie[node.children[i]].accepts {
+			arcs[i] |= KWGNodeAcceptsBit
+		}
+	}
+	arcs[len(arcs)-1] |= KWGNodeIsEndBit
+
+	sigBytes := make([]byte, 4*len(arcs))
+	for i, a := range arcs {
+		binary.LittleEndian.PutUint32(sigBytes[4*i:], a)
+	}
+	sig := string(sigBytes)
+	if idx, ok := s.lists[sig]; ok {
+		s.placed[n] = idx
+		return idx, nil
+	}
+	idx := uint32(len(s.nodes))
+	if uint64(idx)+uint64(len(arcs)) > uint64(KWGNodeArcMask) {
+		return 0, errors.New("word graph is too big for the KWG format")
+	}
+	s.nodes = append(s.nodes, arcs...)
+	s.lists[sig] = idx
+	s.placed[n] = idx
+	return idx, nil
+}
+
+// buildKWGNodes builds the node array of a KWG holding the given words in
+// its DAWG and, if withGaddag is set, in its GADDAG. Identical arc lists
+// are stored once.
+func buildKWGNodes(words []tilemapping.MachineWord, withGaddag bool) ([]uint32, error) {
+	b := newKWGBuilder()
+	dawgRoot := int32(0)
+	gaddagRoot := b.newRoot()
+	for _, w := range words {
+		if len(w) == 0 {
+			return nil, errors.New("cannot add an empty word to a KWG")
+		}
+		for _, ml := range w {
+			if ml.IsBlanked() || ml == 0 && withGaddag {
+				return nil, fmt.Errorf("invalid tile %v in word", ml)
+			}
+		}
+		b.insert(dawgRoot, w)
+		if !withGaddag {
+			continue
+		}
+		// For CARE: ERAC, RAC^E, AC^RE, C^ARE, with ^ (tile 0) as the
+		// separator.
+		b.insert(gaddagRoot, reverse(w))
+		for i := len(w) - 1; i > 0; i-- {
+			entry := append(reverse(w[:i]), 0)
+			b.insert(gaddagRoot, append(entry, w[i:]...))
+		}
+	}
+	s := &serializer{
+		b:      b,
+		nodes:  []uint32{KWGNodeIsEndBit, KWGNodeIsEndBit},
+		lists:  map[string]uint32{},
+		placed: make([]uint32, len(b.trie)),
+	}
+	dawgIdx, err := s.place(dawgRoot)
+	if err != nil {
+		return nil, err
+	}
+	gaddagIdx, err := s.place(gaddagRoot)
+	if err != nil {
+		return nil, err
+	}
+	s.nodes[0] |= dawgIdx
+	s.nodes[1] |= gaddagIdx
+	return s.nodes, nil
+}
@@ -0,0 +1,47 @@
+package kwg
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// graphPaths lists every accepted path from the arc list at nodeIdx, in
+// order, so graphs with different layouts can be compared.
+func graphPaths(t *testing.T, k *KWG, nodeIdx uint32) []string {
+	t.Helper()
+	paths := []string{}
+	err := forEachWordAt(k, nodeIdx, nil, func(w tilemapping.MachineWord) error {
+		paths = append(paths, string(w))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func TestBuildKWGNodesMatchesTrie(t *testing.T) {
+	is := is.New(t)
+	tm := testEnglishLD(t).TileMapping()
+	words := append(randomTestWords(2000), extendTestWords...)
+	mws := make([]tilemapping.MachineWord, len(words))
+	for i, w := range words {
+		mw, err := tilemapping.ToMachineWord(w, tm)
+		is.NoErr(err)
+		mws[i] = mw
+	}
+	for _, withGaddag := range []bool{false, true} {
+		nodes, err := buildKWGNodes(mws, withGaddag)
+		is.NoErr(err)
+		built := newKWG(nodes)
+		trie := newKWG(buildTestNodes(t, mws, withGaddag))
+		// Minimizing shares arc lists, so the built graph is smaller.
+		is.True(len(built.nodes) < len(trie.nodes))
+		is.Equal(graphPaths(t, built, built.ArcIndex(0)), graphPaths(t, trie, trie.ArcIndex(0)))
+		is.Equal(graphPaths(t, built, built.ArcIndex(1)), graphPaths(t, trie, trie.ArcIndex(1)))
+		is.Equal(built.Kind(), trie.Kind())
+	}
+}
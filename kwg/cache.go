@@ -1,7 +1,11 @@
 package kwg
 
 import (
+	"bytes"
+	"compress/gzip"
 	"errors"
+	"io"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -15,11 +19,20 @@ import (
 const (
 	CacheKeyPrefixKWG  = "kwg:"
 	CacheKeyPrefixKBWG = "kbwg:"
+	CacheKeyPrefixWMP  = "wmp:"
+	CacheKeyPrefixKLV  = "klv:"
+	CacheKeyPrefixDAWG = "dawg:"
 )
 
 // loadOpts holds the options settable via LoadOption.
 type loadOpts struct {
-	distName string
+	distName         string
+	wordCountSidecar bool
+	kind             GraphKind
+	kindSet          bool
+	accel            bool
+	prune            bool
+	fingerprint      string
 }
 
 // LoadOption customizes how a KWG/KBWG is loaded. See WithDistribution.
@@ -34,6 +47,45 @@ func WithDistribution(distName string) LoadOption {
 	return func(o *loadOpts) { o.distName = distName }
 }
 
+// WithWordCountSidecar builds the word-count index (see KWG.CountWords) at
+// load time, reading it from a sidecar file next to the graph file (the
+// graph's file name plus WordCountSidecarExt) if there is one, and counting
+// otherwise. The sidecar is written with KWG.SaveWordCounts. It is only used
+// if it was saved for this graph: its node count and node hash must match,
+// and no count may be negative. The counts themselves aren't recomputed, so
+// a sidecar with the right header but wrong counts is trusted.
+func WithWordCountSidecar() LoadOption {
+	return func(o *loadOpts) { o.wordCountSidecar = true }
+}
+
+// WithGraphKind sets the kind of the graph instead of detecting it. Files
+// with the DAWGExt extension are always DAWG-only. Asking for a GADDAG that
+// the file doesn't have fails with ErrNoGADDAG; asking for a DAWG-only graph
+// makes the GADDAG-only functions refuse the graph, but doesn't free the
+// GADDAG's memory. Build and save a DAWG-only graph with BuildKWG for that.
+func WithGraphKind(kind GraphKind) LoadOption {
+	return func(o *loadOpts) {
+		o.kind = kind
+		o.kindSet = true
+	}
+}
+
+// WithAccelerationTables builds per-node tables at load time that make
+// NextNodeIdx, InLetterSet and GetLetterSet constant-time, at the cost of 16
+// bytes of memory per node (four times the size of the graph). It is only
+// supported for KWGs, not KBWGs.
+func WithAccelerationTables() LoadOption {
+	return func(o *loadOpts) { o.accel = true }
+}
+
+// WithPruningTables builds per-node tables at load time that let the
+// anagrammer skip the parts of the DAWG that can't lead to a word with the
+// tiles it has left, which mostly speeds up queries with blanks. They take
+// 16 bytes of memory per node. They are only supported for KWGs, not KBWGs.
+func WithPruningTables() LoadOption {
+	return func(o *loadOpts) { o.prune = true }
+}
+
 func resolveLoadOpts(opts []LoadOption) loadOpts {
 	var o loadOpts
 	for _, opt := range opts {
@@ -42,22 +94,44 @@ func resolveLoadOpts(opts []LoadOption) loadOpts {
 	return o
 }
 
+// cacheKey returns the cache key of the named graph loaded with these
+// options. Options that change the loaded object are part of the key.
+func (o loadOpts) cacheKey(prefix, name string) string {
+	key := prefix + name
+	if o.distName != "" {
+		key += ":" + strings.ToLower(o.distName)
+	}
+	if o.kindSet {
+		key += ":" + o.kind.String()
+	}
+	if o.accel {
+		key += ":accel"
+	}
+	if o.prune {
+		key += ":prune"
+	}
+	return key
+}
+
 // LoadWordGraph loads either a KWG or KBWG based on the file extension. By
 // default, the letter distribution (and thus alphabet) is guessed from the
 // lexicon name; pass WithDistribution to override that guess explicitly.
+//
+// The file may be gzip-compressed, with a .gz extension (e.g. CSW21.kwg.gz).
+// If filename doesn't exist, filename.gz is tried instead.
 func LoadWordGraph[T WordGraphConstraint](cfg *config.Config, filename string, opts ...LoadOption) (T, error) {
-	o := resolveLoadOpts(opts)
-	return loadWordGraph[T](cfg, filename, o.distName)
+	return loadWordGraph[T](cfg, filename, resolveLoadOpts(opts))
 }
 
-func loadWordGraph[T WordGraphConstraint](cfg *config.Config, filename, distName string) (T, error) {
+func loadWordGraph[T WordGraphConstraint](cfg *config.Config, filename string, o loadOpts) (T, error) {
 	log.Debug().Msgf("Loading %v ...", filename)
-	file, filesize, err := cache.Open(filename)
+	file, filesize, err := openGraphFile(filename)
 	var result T
 	if err != nil {
 		return result, err
 	}
 	defer file.Close()
+	filename = strings.TrimSuffix(filename, gzipExt)
 
 	// Determine if it's a KWG or KBWG file based on extension
 	switch any(result).(type) {
@@ -77,39 +151,129 @@ func loadWordGraph[T WordGraphConstraint](cfg *config.Config, filename, distName
 		return result, errors.New("unsupported graph type for loading")
 	}
 
-	// Set lexicon name and alphabet
+	lexname, alph, err := lexiconMetadata(cfg, filename, o.distName)
+	if err != nil {
+		return result, err
+	}
+
+	if _, ok := any(result).(*KBWG); ok && alph.NumLetters() > 64 {
+		// Its tile field is 6 bits.
+		return result, errors.New("alphabet is too big for a KBWG")
+	}
+
+	// We need to set these fields, but the interface doesn't have setters
+	// We need to use type assertions to access the underlying types
+	var k *KWG
+	switch v := any(result).(type) {
+	case *KWG:
+		k = v
+	case *KBWG:
+		k = &v.KWG
+	}
+	k.lexiconName = lexname
+	k.setAlphabet(alph)
+	if !o.kindSet && filepath.Ext(filename) == DAWGExt {
+		o.kind, o.kindSet = GraphKindDAWG, true
+	}
+	if o.kindSet {
+		if err := setKind(result, k, o.kind); err != nil {
+			return result, err
+		}
+	}
+	if o.accel || o.prune {
+		if _, ok := any(result).(*KBWG); ok {
+			return result, errors.New("node tables are not supported for KBWGs")
+		}
+	}
+	if o.accel {
+		if err := k.buildAccelerationTables(); err != nil {
+			return result, err
+		}
+	}
+	if o.prune {
+		if err := k.buildPruningTables(); err != nil {
+			return result, err
+		}
+	}
+	if o.wordCountSidecar {
+		loadWordCountSidecar(k, filename)
+	}
+	if err := checkFingerprint(result, o); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// gzipExt is the extension of gzip-compressed graph files.
+const gzipExt = ".gz"
+
+// openGraphFile opens a graph file, falling back to its gzip-compressed
+// variant if it doesn't exist. Compressed files are decompressed into
+// memory, since the graph scanners need the uncompressed size up front.
+func openGraphFile(filename string) (io.ReadCloser, int, error) {
+	if !strings.HasSuffix(filename, gzipExt) {
+		file, filesize, err := cache.Open(filename)
+		if !errors.Is(err, os.ErrNotExist) {
+			return file, filesize, err
+		}
+		log.Debug().Msgf("Trying %v ...", filename+gzipExt)
+		file, _, gzErr := cache.Open(filename + gzipExt)
+		if errors.Is(gzErr, os.ErrNotExist) {
+			// Neither exists; report the file that was asked for.
+			return nil, 0, err
+		}
+		if gzErr != nil {
+			return nil, 0, gzErr
+		}
+		return gunzipGraphFile(file)
+	}
+	file, _, err := cache.Open(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+	return gunzipGraphFile(file)
+}
+
+// gunzipGraphFile reads and closes a gzip-compressed graph file.
+func gunzipGraphFile(file io.ReadCloser) (io.ReadCloser, int, error) {
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer zr.Close()
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, 0, err
+	}
+	return io.NopCloser(bytes.NewReader(raw)), len(raw), nil
+}
+
+// lexiconMetadata derives the lexicon name from a lexicon file name, and
+// resolves its alphabet from distName or, if that is empty, from a guess
+// based on the lexicon name.
+func lexiconMetadata(cfg *config.Config, filename, distName string) (string, *tilemapping.TileMapping, error) {
 	lexfile := filepath.Base(filename)
 	lexname, found := strings.CutSuffix(lexfile, filepath.Ext(lexfile))
 	if !found {
-		return result, errors.New("filename not in correct format")
+		return "", nil, errors.New("filename not in correct format")
 	}
 
 	// Note: we deliberately use the uncached NamedLetterDistribution here,
-	// not GetDistribution. This function runs inside a locked cache.Load
-	// call (from GetKWG/GetKBWG/etc.), and GetDistribution itself calls
-	// cache.Load, which would deadlock on the non-reentrant cache mutex.
+	// not GetDistribution, whose cache is keyed by distribution name only:
+	// the distribution is read from the same data path as the graph.
 	var ld *tilemapping.LetterDistribution
+	var err error
 	if distName != "" {
 		ld, err = tilemapping.NamedLetterDistribution(cfg, distName)
 	} else {
 		ld, err = tilemapping.ProbableLetterDistribution(cfg, lexname)
 	}
 	if err != nil {
-		return result, err
+		return "", nil, err
 	}
-
-	// We need to set these fields, but the interface doesn't have setters
-	// We need to use type assertions to access the underlying types
-	switch v := any(result).(type) {
-	case *KWG:
-		v.lexiconName = lexname
-		v.alphabet = ld.TileMapping()
-	case *KBWG:
-		v.lexiconName = lexname
-		v.alphabet = ld.TileMapping()
-	}
-
-	return result, nil
+	return lexname, ld.TileMapping(), nil
 }
 
 // LoadKWG loads a KWG from a file (for backward compatibility)
@@ -125,33 +289,30 @@ func LoadKBWG(cfg *config.Config, filename string, opts ...LoadOption) (*KBWG, e
 // CacheLoadFuncKWG is the function that loads a KWG into the global cache
 func CacheLoadFuncKWG(cfg *config.Config, key string) (interface{}, error) {
 	lexiconName := strings.TrimPrefix(key, CacheKeyPrefixKWG)
-	return loadKWGFile(cfg, lexiconName, "")
+	return loadKWGFile(cfg, lexiconName, loadOpts{})
 }
 
 // CacheLoadFuncKBWG is the function that loads a KBWG into the global cache
 func CacheLoadFuncKBWG(cfg *config.Config, key string) (interface{}, error) {
 	lexiconName := strings.TrimPrefix(key, CacheKeyPrefixKBWG)
-	return loadKBWGFile(cfg, lexiconName, "")
+	return loadKBWGFile(cfg, lexiconName, loadOpts{})
 }
 
-func loadKWGFile(cfg *config.Config, lexiconName, distName string) (interface{}, error) {
-	dataPath := cfg.DataPath
-	kwgPrefix := cfg.KWGPathPrefix
-
-	if kwgPrefix == "" {
-		return loadWordGraph[*KWG](cfg, filepath.Join(dataPath, "lexica", "gaddag", lexiconName+".kwg"), distName)
+// lexiconFilePath returns the path of the named lexicon file with the given
+// extension, e.g. DataPath/lexica/gaddag/CSW21.kwg.
+func lexiconFilePath(cfg *config.Config, lexiconName, ext string) string {
+	if cfg.KWGPathPrefix == "" {
+		return filepath.Join(cfg.DataPath, "lexica", "gaddag", lexiconName+ext)
 	}
-	return loadWordGraph[*KWG](cfg, filepath.Join(dataPath, "lexica", "gaddag", kwgPrefix, lexiconName+".kwg"), distName)
+	return filepath.Join(cfg.DataPath, "lexica", "gaddag", cfg.KWGPathPrefix, lexiconName+ext)
 }
 
-func loadKBWGFile(cfg *config.Config, lexiconName, distName string) (interface{}, error) {
-	dataPath := cfg.DataPath
-	kwgPrefix := cfg.KWGPathPrefix
+func loadKWGFile(cfg *config.Config, lexiconName string, o loadOpts) (interface{}, error) {
+	return loadWordGraph[*KWG](cfg, lexiconFilePath(cfg, lexiconName, ".kwg"), o)
+}
 
-	if kwgPrefix == "" {
-		return loadWordGraph[*KBWG](cfg, filepath.Join(dataPath, "lexica", "gaddag", lexiconName+".kbwg"), distName)
-	}
-	return loadWordGraph[*KBWG](cfg, filepath.Join(dataPath, "lexica", "gaddag", kwgPrefix, lexiconName+".kbwg"), distName)
+func loadKBWGFile(cfg *config.Config, lexiconName string, o loadOpts) (interface{}, error) {
+	return loadWordGraph[*KBWG](cfg, lexiconFilePath(cfg, lexiconName, ".kbwg"), o)
 }
 
 // GetGraph loads a named KWG or KBWG from the cache or from a file. By
@@ -182,14 +343,19 @@ func GetGraph[T WordGraphConstraint](cfg *config.Config, name string, opts ...Lo
 // letter distribution (and thus alphabet) is guessed from the lexicon name;
 // pass WithDistribution to override that guess explicitly, which is required
 // for lexicon names word-golib has no built-in knowledge of.
+//
+// The KWG is read from <name>.kwg or, if that doesn't exist, from the
+// gzip-compressed <name>.kwg.gz. The name may be an alias or a family name;
+// see ResolveLexiconName.
 func GetKWG(cfg *config.Config, name string, opts ...LoadOption) (*KWG, error) {
 	o := resolveLoadOpts(opts)
-	key := CacheKeyPrefixKWG + name
-	if o.distName != "" {
-		key += ":" + strings.ToLower(o.distName)
+	name, err := ResolveLexiconName(cfg, name, ".kwg")
+	if err != nil {
+		return nil, err
 	}
+	key := o.cacheKey(CacheKeyPrefixKWG, name)
 	obj, err := cache.Load(cfg, key, func(cfg *config.Config, _ string) (interface{}, error) {
-		return loadKWGFile(cfg, name, o.distName)
+		return loadKWGFile(cfg, name, o)
 	})
 	if err != nil {
 		return nil, err
@@ -199,18 +365,22 @@ func GetKWG(cfg *config.Config, name string, opts ...LoadOption) (*KWG, error) {
 	if !ok {
 		return nil, errors.New("could not convert cached object to KWG")
 	}
+	if err := checkFingerprint(kwg, o); err != nil {
+		return nil, err
+	}
 	return kwg, nil
 }
 
 // GetKBWG loads a named KBWG from the cache or from a file. See GetKWG.
 func GetKBWG(cfg *config.Config, name string, opts ...LoadOption) (*KBWG, error) {
 	o := resolveLoadOpts(opts)
-	key := CacheKeyPrefixKBWG + name
-	if o.distName != "" {
-		key += ":" + strings.ToLower(o.distName)
+	name, err := ResolveLexiconName(cfg, name, ".kbwg")
+	if err != nil {
+		return nil, err
 	}
+	key := o.cacheKey(CacheKeyPrefixKBWG, name)
 	obj, err := cache.Load(cfg, key, func(cfg *config.Config, _ string) (interface{}, error) {
-		return loadKBWGFile(cfg, name, o.distName)
+		return loadKBWGFile(cfg, name, o)
 	})
 	if err != nil {
 		return nil, err
@@ -220,5 +390,8 @@ func GetKBWG(cfg *config.Config, name string, opts ...LoadOption) (*KBWG, error)
 	if !ok {
 		return nil, errors.New("could not convert cached object to KBWG")
 	}
+	if err := checkFingerprint(kbwg, o); err != nil {
+		return nil, err
+	}
 	return kbwg, nil
 }
@@ -1,8 +1,14 @@
 package kwg
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/matryer/is"
@@ -242,3 +248,115 @@ func TestGetGraphWithDistribution_UnrecognizedLexiconName_ErrorsWithoutOverride(
 	_, err := GetGraph[*KWG](cfg, "ZZZLEX15", WithDistribution(""))
 	is.True(err != nil)
 }
+
+// writeGzippedGraph writes the nodes of k, gzip-compressed, to path.
+func writeGzippedGraph(t *testing.T, k *KWG, path string) {
+	t.Helper()
+	is := is.New(t)
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	is.NoErr(binary.Write(zw, binary.LittleEndian, k.Nodes()))
+	is.NoErr(zw.Close())
+	is.NoErr(os.WriteFile(path, buf.Bytes(), 0644))
+}
+
+func TestLoadWordGraph_GzippedKWG(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	k := writeTestLexicon(t, cfg, "ZZZLEX30", ".kwg.gz", "AB", "BA", "ABBA")
+	path := lexiconFilePath(cfg, "ZZZLEX30", ".kwg.gz")
+
+	g, err := LoadWordGraph[*KWG](cfg, path, WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	is.Equal(g.LexiconName(), "ZZZLEX30")
+	is.Equal(g.Nodes(), k.Nodes())
+	is.True(FindWord(g, "ABBA"))
+
+	// The uncompressed name falls back to the compressed file.
+	g, err = LoadKWG(cfg, lexiconFilePath(cfg, "ZZZLEX30", ".kwg"), WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	is.Equal(g.Nodes(), k.Nodes())
+}
+
+func TestGetKWG_ProbesGzippedFile(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	k := writeTestLexicon(t, cfg, "ZZZLEX31", ".kwg.gz", "AB", "BA")
+	writeTestLexicon(t, cfg, "ZZZLEX31", ".kbwg.gz", "AB", "BA")
+
+	g, err := GetKWG(cfg, "ZZZLEX31", WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	is.Equal(g.LexiconName(), "ZZZLEX31")
+	is.Equal(g.Nodes(), k.Nodes())
+
+	kb, err := GetKBWG(cfg, "ZZZLEX31", WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	is.Equal(kb.LexiconName(), "ZZZLEX31")
+	is.Equal(kb.Nodes(), k.Nodes())
+}
+
+func TestGetKWG_MissingFileNamesUncompressedPath(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	writeFakeDist(t, cfg, fakeDistOne, fakeDistOneContent)
+
+	_, err := GetKWG(cfg, "ZZZLEX33", WithDistribution(fakeDistOne))
+	is.True(errors.Is(err, os.ErrNotExist))
+	is.True(strings.Contains(err.Error(), "ZZZLEX33.kwg:"))
+}
+
+func TestLoadWordGraph_CorruptGzip_Errors(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	writeFakeDist(t, cfg, fakeDistOne, fakeDistOneContent)
+	path := writeFakeLexFile(t, cfg, "ZZZLEX32", ".kwg.gz")
+
+	_, err := LoadWordGraph[*KWG](cfg, path, WithDistribution(fakeDistOne))
+	is.True(err != nil)
+}
+
+func TestLoadWordGraph_KWG_DistributionFromRegistryFile(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	writeFakeDist(t, cfg, fakeDistTwo, fakeDistTwoContent)
+	is.NoErr(os.WriteFile(filepath.Join(cfg.DataPath, "lexica", tilemapping.LexiconRegistryFile),
+		[]byte("ZZZREG*,"+fakeDistTwo+",,,\n"), 0644))
+	path := writeFakeLexFile(t, cfg, "ZZZREG01", ".kwg")
+
+	g, err := LoadWordGraph[*KWG](cfg, path)
+	is.NoErr(err)
+	is.Equal(g.GetAlphabet().NumLetters(), uint8(4))
+}
+
+func TestGetKWG_Concurrent(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	const families = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	for _, f := range families {
+		writeTestLexicon(t, cfg, "ZZZCONC"+string(f)+"1", ".kwg", "AB", "BA")
+	}
+
+	var wg sync.WaitGroup
+	graphs := make([]*KWG, 4*len(families))
+	errs := make([]error, len(graphs))
+	for i := range graphs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Families and full names, so that names are resolved while
+			// other graphs load.
+			name := "ZZZCONC" + string(families[i%len(families)])
+			if i%2 == 1 {
+				name += "1"
+			}
+			graphs[i], errs[i] = GetKWG(cfg, name, WithDistribution(fakeDistOne))
+		}()
+	}
+	wg.Wait()
+	for i, g := range graphs {
+		is.NoErr(errs[i])
+		is.True(FindWord(g, "AB"))
+		// Every name of a lexicon gets the one cached graph.
+		is.True(g == graphs[i%len(families)])
+	}
+}
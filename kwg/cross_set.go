@@ -0,0 +1,140 @@
+package kwg
+
+import (
+	"errors"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// errWideAlphabet is returned by functions that use LetterSets when the
+// alphabet is too big for them.
+var errWideAlphabet = errors.New("alphabet is too big for a LetterSet; use the wide variant")
+
+// ComputeCrossSet returns the set of letters that can be placed on a square
+// with the tile run prefix before it and the tile run suffix after it (above
+// and below it, for a horizontal play), so that prefix + letter + suffix is
+// a word. It also returns the cross-score: the score of the prefix and
+// suffix tiles, with designated blanks scoring as blanks.
+//
+// If both runs are empty, the square has no cross-word, so every letter in
+// the alphabet is allowed and the cross-score is 0. Otherwise the GADDAG is
+// used, so DAWG-only graphs fail with ErrNoGADDAG. Wide alphabets need
+// ComputeWideCrossSet.
+func ComputeCrossSet[T WordGraphConstraint](d T, ld *tilemapping.LetterDistribution,
+	prefix, suffix tilemapping.MachineWord) (tilemapping.LetterSet, int, error) {
+
+	if graphBase(d).wide {
+		return 0, 0, errWideAlphabet
+	}
+	score := ld.WordScore(prefix) + ld.WordScore(suffix)
+	if len(prefix) == 0 && len(suffix) == 0 {
+		// Letters are tiles 1 to NumLetters-1.
+		n := d.GetAlphabet().NumLetters()
+		return tilemapping.LetterSet(1)<<n - 2, 0, nil
+	}
+	nodeIdx, perArc, err := crossSetStart(d, prefix, suffix)
+	if err != nil || nodeIdx == 0 {
+		return 0, score, err
+	}
+	if !perArc {
+		return d.GetLetterSet(nodeIdx), score, nil
+	}
+	var ls tilemapping.LetterSet
+	for i := nodeIdx; ; i++ {
+		if crossSetArcFits(d, i, prefix) {
+			ls |= 1 << d.Tile(i)
+		}
+		if d.IsEnd(i) {
+			break
+		}
+	}
+	return ls, score, nil
+}
+
+// ComputeWideCrossSet is ComputeCrossSet for alphabets of any size.
+func ComputeWideCrossSet[T WordGraphConstraint](d T, ld *tilemapping.LetterDistribution,
+	prefix, suffix tilemapping.MachineWord) (tilemapping.WideLetterSet, int, error) {
+
+	var ls tilemapping.WideLetterSet
+	score := ld.WordScore(prefix) + ld.WordScore(suffix)
+	if len(prefix) == 0 && len(suffix) == 0 {
+		for ml := tilemapping.MachineLetter(1); ml < tilemapping.MachineLetter(d.GetAlphabet().NumLetters()); ml++ {
+			ls.Add(ml)
+		}
+		return ls, 0, nil
+	}
+	nodeIdx, perArc, err := crossSetStart(d, prefix, suffix)
+	if err != nil || nodeIdx == 0 {
+		return ls, score, err
+	}
+	if !perArc {
+		return wideLetterSetAt(d, nodeIdx), score, nil
+	}
+	for i := nodeIdx; ; i++ {
+		if crossSetArcFits(d, i, prefix) {
+			ls.Add(tilemapping.MachineLetter(d.Tile(i)))
+		}
+		if d.IsEnd(i) {
+			break
+		}
+	}
+	return ls, score, nil
+}
+
+// crossSetStart walks the GADDAG to the arc list the cross-set is read
+// from, which is 0 if there is none. If perArc is false, the cross-set is
+// the letter set of that arc list. Otherwise each of its arcs is a letter
+// that must be checked with crossSetArcFits.
+func crossSetStart[T WordGraphConstraint](d T, prefix, suffix tilemapping.MachineWord) (nodeIdx uint32, perArc bool, err error) {
+	if !graphBase(d).HasGADDAG() {
+		return 0, false, ErrNoGADDAG
+	}
+	root := d.ArcIndex(1)
+
+	if len(suffix) == 0 {
+		// rev(prefix) ^ letter is a GADDAG path for every such word.
+		nodeIdx = walkReversed(d, root, prefix)
+		if nodeIdx == 0 {
+			return 0, false, nil
+		}
+		return d.NextNodeIdx(nodeIdx, 0), false, nil
+	}
+
+	// The separator-less path is the whole word reversed:
+	// rev(suffix) letter rev(prefix).
+	nodeIdx = walkReversed(d, root, suffix)
+	return nodeIdx, len(prefix) > 0, nil
+}
+
+// crossSetArcFits returns whether the letter on arc i, reached by walking
+// the reversed suffix, is followed by the reversed prefix in the GADDAG.
+func crossSetArcFits[T WordGraphConstraint](d T, i uint32, prefix tilemapping.MachineWord) bool {
+	if d.Tile(i) == 0 {
+		return false
+	}
+	child := walkReversed(d, d.ArcIndex(i), prefix[1:])
+	return child != 0 && d.InLetterSet(prefix[0], child)
+}
+
+// walkReversed follows the tiles of run from last to first, starting at the
+// arc list nodeIdx, and returns the arc list reached, or 0 if there is no
+// such path.
+func walkReversed[T WordGraphConstraint](d T, nodeIdx uint32, run tilemapping.MachineWord) uint32 {
+	for i := len(run) - 1; i >= 0 && nodeIdx != 0; i-- {
+		nodeIdx = d.NextNodeIdx(nodeIdx, run[i].Unblank())
+	}
+	return nodeIdx
+}
+
+// wideLetterSetAt is GetLetterSet for alphabets of any size.
+func wideLetterSetAt[T WordGraphConstraint](d T, nodeIdx uint32) tilemapping.WideLetterSet {
+	var ls tilemapping.WideLetterSet
+	for i := nodeIdx; ; i++ {
+		if d.Accepts(i) {
+			ls.Add(tilemapping.MachineLetter(d.Tile(i)))
+		}
+		if d.IsEnd(i) {
+			return ls
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package kwg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+func TestComputeCrossSetMatchesBruteForce(t *testing.T) {
+	is := is.New(t)
+	ld := testEnglishLD(t)
+	k := buildTestKWGWithDist(t, ld, "ZZZCROSS", "AB", "AD", "AE", "AT", "BA", "BE", "CAB",
+		"CAT", "CAR", "CARE", "CARES", "CARET", "DARE", "EAT", "OAT", "SCAR", "SCARE", "TAB", "TAE", "TEA")
+
+	runs := []string{"", "A", "E", "C", "CA", "AR", "ARE", "CAR", "RE", "S", "ZZ", "Q", "a", "cA"}
+	for _, p := range runs {
+		for _, s := range runs {
+			prefix := testMW(t, k, p)
+			suffix := testMW(t, k, s)
+			ls, score, err := ComputeCrossSet(k, ld, prefix, suffix)
+			is.NoErr(err)
+			is.Equal(score, ld.WordScore(prefix)+ld.WordScore(suffix))
+
+			var want tilemapping.LetterSet
+			for ml := tilemapping.MachineLetter(1); ml < tilemapping.MachineLetter(k.GetAlphabet().NumLetters()); ml++ {
+				word := append(append(append(tilemapping.MachineWord{}, prefix...), ml), suffix...)
+				if p == "" && s == "" || FindMachineWord(k, unblankedCopy(word)) {
+					want |= 1 << ml
+				}
+			}
+			if ls != want {
+				t.Errorf("cross-set of %q_%q: got %b, want %b", p, s, ls, want)
+			}
+		}
+	}
+}
+
+func TestComputeCrossSetNeedsGADDAG(t *testing.T) {
+	is := is.New(t)
+	ld := testEnglishLD(t)
+	k, err := BuildKWG(ld.TileMapping(), "ZZZCROSSDAWG", []string{"CAT"}, GraphKindDAWG)
+	is.NoErr(err)
+	_, _, err = ComputeCrossSet(k, ld, testMW(t, k, "CA"), nil)
+	is.True(errors.Is(err, ErrNoGADDAG))
+	// No cross-word doesn't need the GADDAG.
+	ls, score, err := ComputeCrossSet(k, ld, nil, nil)
+	is.NoErr(err)
+	is.Equal(score, 0)
+	is.Equal(ls&1, tilemapping.LetterSet(0))
+	is.True(ls&(1<<26) != 0)
+	is.Equal(ls>>27, tilemapping.LetterSet(0))
+}
+
+// wideTestLD returns a distribution with more letters than fit in a
+// LetterSet: A-Z, then Cyrillic А-Я, then Greek Α-Ρ.
+func wideTestLD(t testing.TB) *tilemapping.LetterDistribution {
+	t.Helper()
+	var sb strings.Builder
+	sb.WriteString("?,2,0,0\n")
+	add := func(from, to rune) {
+		for r := from; r <= to; r++ {
+			fmt.Fprintf(&sb, "%c,1,1,0\n", r)
+		}
+	}
+	add('A', 'Z')
+	add('А', 'Я')
+	add('Α', 'Ρ')
+	ld, err := tilemapping.ScanLetterDistribution(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ld
+}
+
+func TestComputeWideCrossSet(t *testing.T) {
+	is := is.New(t)
+	ld := wideTestLD(t)
+	is.True(ld.TileMapping().IsWide())
+	k := buildTestKWGWithDist(t, ld, "ZZZWIDE", "CAT", "CAΡ", "CAЯ", "AΡ", "ЯA", "ΡAΡ", "ΡAЯ")
+	is.True(k.IsWide())
+	is.True(!buildTestKWG(t, "TEST", "CAT").IsWide())
+
+	is.True(FindWord(k, "CAЯ"))
+	is.True(!FindWord(k, "CAБ"))
+
+	_, _, err := ComputeCrossSet(k, ld, testMW(t, k, "CA"), nil)
+	is.True(err != nil)
+	_, err = NewExtender(k)
+	is.True(err != nil)
+	da := KWGAnagrammer{}
+	is.Equal(da.InitForMachineWord(k, testMW(t, k, "CAЯ")), errWideAlphabet)
+	is.Equal(da.Anagram(k, func(tilemapping.MachineWord) error { return nil }), errWideAlphabet)
+
+	runs := []string{"", "A", "CA", "ΡA", "Я", "Ρ", "ΡAΡ"}
+	for _, p := range runs {
+		for _, s := range runs {
+			prefix := testMW(t, k, p)
+			suffix := testMW(t, k, s)
+			ls, score, err := ComputeWideCrossSet(k, ld, prefix, suffix)
+			is.NoErr(err)
+			is.Equal(score, ld.WordScore(prefix)+ld.WordScore(suffix))
+
+			var want tilemapping.WideLetterSet
+			for ml := tilemapping.MachineLetter(1); ml < tilemapping.MachineLetter(k.GetAlphabet().NumLetters()); ml++ {
+				word := append(append(append(tilemapping.MachineWord{}, prefix...), ml), suffix...)
+				if p == "" && s == "" || FindMachineWord(k, word) {
+					want.Add(ml)
+				}
+			}
+			if ls != want {
+				t.Errorf("wide cross-set of %q_%q: got %v, want %v", p, s, ls, want)
+			}
+		}
+	}
+
+	// The letters that end a word after CA include some past the first 64.
+	ca := k.NextNodeIdx(k.NextNodeIdx(k.ArcIndex(0), testMW(t, k, "C")[0]), testMW(t, k, "A")[0])
+	ls := k.GetWideLetterSet(ca)
+	is.Equal(ls.Count(), 3)
+	is.True(ls.Contains(testMW(t, k, "Я")[0]))
+	is.True(ls.Contains(testMW(t, k, "Ρ")[0]))
+	_, narrow := ls.Narrow()
+	is.True(!narrow)
+}
@@ -0,0 +1,162 @@
+package kwg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/domino14/word-golib/cache"
+	"github.com/domino14/word-golib/config"
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// GraphKind tells which halves a word graph has.
+type GraphKind int
+
+const (
+	// GraphKindDAWGAndGADDAG is a full KWG: nodes[0] points to the DAWG and
+	// nodes[1] to the GADDAG.
+	GraphKindDAWGAndGADDAG GraphKind = iota
+	// GraphKindDAWG only has a DAWG; nodes[1], if present, points nowhere.
+	// It takes about half the memory of a full KWG, and is all that word
+	// lookups and anagramming need.
+	GraphKindDAWG
+)
+
+func (g GraphKind) String() string {
+	switch g {
+	case GraphKindDAWGAndGADDAG:
+		return "dawg+gaddag"
+	case GraphKindDAWG:
+		return "dawg"
+	}
+	return fmt.Sprintf("GraphKind(%d)", int(g))
+}
+
+// DAWGExt is the file extension of DAWG-only word graphs. They are in the
+// KWG format, with an empty GADDAG.
+const DAWGExt = ".dawg"
+
+// ErrNoGADDAG is returned by functions that need a GADDAG when they are
+// given a DAWG-only word graph.
+var ErrNoGADDAG = errors.New("word graph has no GADDAG")
+
+// detectGraphKind tells a DAWG-only graph from a full one by whether its
+// GADDAG root points anywhere.
+func detectGraphKind[T WordGraphConstraint](d T, numNodes int) GraphKind {
+	if numNodes < 2 || d.ArcIndex(1) == 0 {
+		return GraphKindDAWG
+	}
+	return GraphKindDAWGAndGADDAG
+}
+
+// Kind returns whether the graph has a GADDAG.
+func (k *KWG) Kind() GraphKind {
+	return k.kind
+}
+
+// HasGADDAG returns whether the graph has a GADDAG.
+func (k *KWG) HasGADDAG() bool {
+	return k.kind == GraphKindDAWGAndGADDAG
+}
+
+// setKind overrides the detected kind of a graph. A graph can always be
+// treated as DAWG-only, but a GADDAG can't be made up.
+func setKind[T WordGraphConstraint](d T, k *KWG, kind GraphKind) error {
+	switch kind {
+	case GraphKindDAWG:
+	case GraphKindDAWGAndGADDAG:
+		if detectGraphKind(d, len(k.nodes)) != GraphKindDAWGAndGADDAG {
+			return ErrNoGADDAG
+		}
+	default:
+		return fmt.Errorf("unknown graph kind %v", kind)
+	}
+	k.kind = kind
+	return nil
+}
+
+// BuildKWG builds a word graph of the given kind holding the given words,
+// which are in user-visible tiles over tm. The words don't need to be
+// sorted or unique. Use Save to write the graph to a .kwg or .dawg file.
+func BuildKWG(tm *tilemapping.TileMapping, lexiconName string, words []string, kind GraphKind) (*KWG, error) {
+	if kind != GraphKindDAWG && kind != GraphKindDAWGAndGADDAG {
+		return nil, fmt.Errorf("unknown graph kind %v", kind)
+	}
+	mws := make([]tilemapping.MachineWord, len(words))
+	for i, w := range words {
+		mw, err := tilemapping.ToMachineWord(w, tm)
+		if err != nil {
+			return nil, err
+		}
+		mws[i] = mw
+	}
+	nodes, err := buildKWGNodes(mws, kind == GraphKindDAWGAndGADDAG)
+	if err != nil {
+		return nil, err
+	}
+	k := newKWG(nodes)
+	k.setAlphabet(tm)
+	k.lexiconName = lexiconName
+	// An empty word list has no DAWG either, so detection can't tell.
+	k.kind = kind
+	return k, nil
+}
+
+// Save writes the node array of the graph to w, in the format read by
+// ScanKWG.
+func (k *KWG) Save(w io.Writer) error {
+	return binary.Write(w, binary.LittleEndian, k.nodes)
+}
+
+// CacheLoadFuncDAWG is the function that loads a DAWG-only graph into the
+// global cache
+func CacheLoadFuncDAWG(cfg *config.Config, key string) (interface{}, error) {
+	lexiconName := strings.TrimPrefix(key, CacheKeyPrefixDAWG)
+	return loadDAWGFile(cfg, lexiconName, loadOpts{})
+}
+
+func loadDAWGFile(cfg *config.Config, lexiconName string, o loadOpts) (interface{}, error) {
+	return loadWordGraph[*KWG](cfg, lexiconFilePath(cfg, lexiconName, DAWGExt), o)
+}
+
+// GetDAWG loads a named DAWG-only graph from the cache or from a file. The
+// file lives next to the KWGs, as <name>.dawg or <name>.dawg.gz. See GetKWG
+// for the options.
+func GetDAWG(cfg *config.Config, name string, opts ...LoadOption) (*KWG, error) {
+	o := resolveLoadOpts(opts)
+	name, err := ResolveLexiconName(cfg, name, DAWGExt)
+	if err != nil {
+		return nil, err
+	}
+	key := o.cacheKey(CacheKeyPrefixDAWG, name)
+	obj, err := cache.Load(cfg, key, func(cfg *config.Config, _ string) (interface{}, error) {
+		return loadDAWGFile(cfg, name, o)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	kwg, ok := obj.(*KWG)
+	if !ok {
+		return nil, errors.New("could not convert cached object to KWG")
+	}
+	if err := checkFingerprint(kwg, o); err != nil {
+		return nil, err
+	}
+	return kwg, nil
+}
+
+// graphBase returns the KWG a KWG or KBWG is built on, for the fields they
+// share.
+func graphBase[T WordGraphConstraint](d T) *KWG {
+	switch v := any(d).(type) {
+	case *KWG:
+		return v
+	case *KBWG:
+		return &v.KWG
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package kwg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+func TestBuildKWGKinds(t *testing.T) {
+	is := is.New(t)
+	tm := testEnglishLD(t).TileMapping()
+	words := []string{"CARE", "CARES", "SCARE", "AR", "ARE"}
+
+	full, err := BuildKWG(tm, "ZZZFULL", words, GraphKindDAWGAndGADDAG)
+	is.NoErr(err)
+	is.Equal(full.Kind(), GraphKindDAWGAndGADDAG)
+	is.True(full.HasGADDAG())
+	is.Equal(full.GetRootNodeIndex(), full.ArcIndex(1))
+
+	dawg, err := BuildKWG(tm, "ZZZDAWG", words, GraphKindDAWG)
+	is.NoErr(err)
+	is.Equal(dawg.Kind(), GraphKindDAWG)
+	is.True(!dawg.HasGADDAG())
+	is.Equal(dawg.GetRootNodeIndex(), dawg.ArcIndex(0))
+	is.Equal(dawg.LexiconName(), "ZZZDAWG")
+	is.True(len(dawg.Nodes()) < len(full.Nodes())/2)
+
+	for _, w := range words {
+		is.True(FindWord(dawg, w))
+	}
+	is.True(!FindWord(dawg, "SCAR"))
+
+	care := testMW(t, dawg, "CARE")
+	_, err = LookupHooks(dawg, care, FrontHooks)
+	is.True(errors.Is(err, ErrNoGADDAG))
+	is.Equal(FindHooks(dawg, care, FrontHooks), nil)
+	hooks, err := LookupHooks(dawg, care, BackHooks)
+	is.NoErr(err)
+	is.Equal(hooks, []tilemapping.MachineLetter(testMW(t, dawg, "S")))
+	hooks, err = LookupHooks(full, care, FrontHooks)
+	is.NoErr(err)
+	is.Equal(hooks, []tilemapping.MachineLetter(testMW(t, full, "S")))
+
+	is.True(errors.Is(WriteAnnotatedWordList(dawg, &bytes.Buffer{}), ErrNoGADDAG))
+
+	_, err = BuildKWG(tm, "ZZZBAD", words, GraphKind(7))
+	is.True(err != nil)
+}
+
+func TestLoadDAWGFiles(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	writeFakeDist(t, cfg, fakeDistOne, fakeDistOneContent)
+	ld, err := tilemapping.NamedLetterDistribution(cfg, fakeDistOne)
+	is.NoErr(err)
+	words := []string{"AB", "BA", "ABBA"}
+
+	save := func(k *KWG, path string) {
+		buf := &bytes.Buffer{}
+		is.NoErr(k.Save(buf))
+		is.NoErr(os.WriteFile(path, buf.Bytes(), 0644))
+	}
+	dawg, err := BuildKWG(ld.TileMapping(), "ZZZLEX40", words, GraphKindDAWG)
+	is.NoErr(err)
+	full, err := BuildKWG(ld.TileMapping(), "ZZZLEX40", words, GraphKindDAWGAndGADDAG)
+	is.NoErr(err)
+	save(dawg, lexiconFilePath(cfg, "ZZZLEX40", DAWGExt))
+	save(full, lexiconFilePath(cfg, "ZZZLEX40", ".kwg"))
+	// A DAWG-only graph with a .kwg name is detected as such.
+	save(dawg, lexiconFilePath(cfg, "ZZZLEX41", ".kwg"))
+
+	g, err := GetDAWG(cfg, "ZZZLEX40", WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	is.Equal(g.LexiconName(), "ZZZLEX40")
+	is.Equal(g.Kind(), GraphKindDAWG)
+	is.Equal(g.Nodes(), dawg.Nodes())
+	is.True(FindWord(g, "ABBA"))
+
+	g, err = GetKWG(cfg, "ZZZLEX41", WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	is.Equal(g.Kind(), GraphKindDAWG)
+
+	_, err = GetKWG(cfg, "ZZZLEX41", WithDistribution(fakeDistOne),
+		WithGraphKind(GraphKindDAWGAndGADDAG))
+	is.True(errors.Is(err, ErrNoGADDAG))
+
+	// Forcing a full graph to be DAWG-only doesn't affect the cached full one.
+	g, err = GetKWG(cfg, "ZZZLEX40", WithDistribution(fakeDistOne), WithGraphKind(GraphKindDAWG))
+	is.NoErr(err)
+	is.Equal(g.Kind(), GraphKindDAWG)
+	g, err = GetKWG(cfg, "ZZZLEX40", WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	is.Equal(g.Kind(), GraphKindDAWGAndGADDAG)
+}
+
+// toKBWGNodes converts KWG nodes to the KBWG layout.
+func toKBWGNodes(nodes []uint32) []uint32 {
+	out := make([]uint32, len(nodes))
+	for i, n := range nodes {
+		out[i] = n>>KWGNodeTileShift | (n&KWGNodeArcMask)<<8
+		if n&KWGNodeIsEndBit != 0 {
+			out[i] |= 0x40
+		}
+		if n&KWGNodeAcceptsBit != 0 {
+			out[i] |= 0x80
+		}
+	}
+	return out
+}
+
+func TestKBWGGraphKind(t *testing.T) {
+	is := is.New(t)
+	tm := testEnglishLD(t).TileMapping()
+	for _, kind := range []GraphKind{GraphKindDAWG, GraphKindDAWGAndGADDAG} {
+		k, err := BuildKWG(tm, "ZZZKBWG", []string{"CARE", "ACRE"}, kind)
+		is.NoErr(err)
+		buf := &bytes.Buffer{}
+		is.NoErr(binary.Write(buf, binary.LittleEndian, toKBWGNodes(k.Nodes())))
+		kb, err := ScanKBWG(buf, buf.Len())
+		is.NoErr(err)
+		is.Equal(kb.Kind(), kind)
+		is.Equal(kb.GetRootNodeIndex(), k.GetRootNodeIndex())
+	}
+}
@@ -0,0 +1,164 @@
+package kwg
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// WriteWordList writes every word in the DAWG of d to w, one per line, in
+// DAWG order (which is alphabetical in tile order).
+func WriteWordList[T WordGraphConstraint](d T, w io.Writer) error {
+	alph := d.GetAlphabet()
+	if alph == nil {
+		return errors.New("word graph has no alphabet")
+	}
+	bw := bufio.NewWriter(w)
+	err := forEachWordAt(d, d.ArcIndex(0), make(tilemapping.MachineWord, 0, 16),
+		func(word tilemapping.MachineWord) error {
+			_, err := fmt.Fprintln(bw, word.UserVisible(alph))
+			return err
+		})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// WriteAnnotatedWordList writes every word in the KWG to w, one per line, in
+// DAWG order, annotated in the style of Zyzzyva's hook lists:
+//
+//	front-hooks ·WORD· back-hooks
+//
+// The three fields are separated by tabs and hooks are lowercase. A dot on
+// either side of the word means the word is still valid with its letter on
+// that side removed (an inner hook). For example, in a lexicon with CAR,
+// CARE, CARED, CARES and SCARE, CARE is written as "s\tCARE·\tds".
+//
+// Front hooks need a GADDAG, so this fails with ErrNoGADDAG on a DAWG-only
+// graph.
+func WriteAnnotatedWordList(kwg *KWG, w io.Writer) error {
+	alph := kwg.GetAlphabet()
+	if alph == nil {
+		return errors.New("word graph has no alphabet")
+	}
+	if !kwg.HasGADDAG() {
+		return ErrNoGADDAG
+	}
+	bw := bufio.NewWriter(w)
+	hookString := func(hooks []tilemapping.MachineLetter) string {
+		var sb strings.Builder
+		for _, ml := range hooks {
+			sb.WriteString(strings.ToLower(alph.Letter(ml)))
+		}
+		return sb.String()
+	}
+	err := forEachWordAt(kwg, kwg.ArcIndex(0), make(tilemapping.MachineWord, 0, 16),
+		func(word tilemapping.MachineWord) error {
+			front := hookString(FindHooks(kwg, word, FrontHooks))
+			back := hookString(FindHooks(kwg, word, BackHooks))
+			frontDot, backDot := "", ""
+			if FindInnerHook(kwg, word, FrontInnerHook) {
+				frontDot = "·"
+			}
+			if FindInnerHook(kwg, word, BackInnerHook) {
+				backDot = "·"
+			}
+			_, err := fmt.Fprintf(bw, "%s\t%s%s%s\t%s\n",
+				front, frontDot, word.UserVisible(alph), backDot, back)
+			return err
+		})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// DOTOptions controls WriteDOT.
+type DOTOptions struct {
+	// Root is the index of the node the rendering starts at. The whole arc
+	// list from Root onwards is drawn. 0 is the DAWG root node and 1 the
+	// GADDAG root node.
+	Root uint32
+	// MaxDepth is the number of arcs to follow down from the root list. Nodes
+	// whose children are cut off get a dotted edge to an ellipsis. If it is
+	// 0 or less, the whole subgraph is drawn, which is only sensible for
+	// small graphs.
+	MaxDepth int
+}
+
+// WriteDOT writes a Graphviz DOT rendering of the subgraph of d rooted at
+// opts.Root. Every node (arc) in the node array is drawn as one vertex,
+// labeled with its index and tile; tile 0 (the GADDAG separator) is drawn
+// as ^. Solid edges go to a node's arc list and dashed edges to the next
+// node in the same list. Accepting nodes are drawn as double circles and
+// nodes that end their arc list are shaded.
+func WriteDOT[T WordGraphConstraint](d T, w io.Writer, opts DOTOptions) error {
+	numNodes := len(graphBase(d).nodes)
+	if int(opts.Root) >= numNodes {
+		return fmt.Errorf("node index %d out of range, graph has %d nodes", opts.Root, numNodes)
+	}
+	alph := d.GetAlphabet()
+	tileLabel := func(t uint8) string {
+		if t == 0 {
+			return "^"
+		}
+		if alph == nil {
+			return strconv.Itoa(int(t))
+		}
+		return alph.Letter(tilemapping.MachineLetter(t))
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph kwg {")
+	fmt.Fprintln(bw, "\tnode [shape=circle];")
+
+	type listAt struct {
+		idx   uint32
+		depth int
+	}
+	seen := map[uint32]bool{opts.Root: true}
+	// Arc lists can share their tails, so a node can be reached from more
+	// than one list start; each node is drawn once.
+	drawn := map[uint32]bool{}
+	queue := []listAt{{opts.Root, 0}}
+	for len(queue) > 0 {
+		l := queue[0]
+		queue = queue[1:]
+		for i := l.idx; !drawn[i]; i++ {
+			drawn[i] = true
+			attrs := fmt.Sprintf("label=%q", fmt.Sprintf("%d\n%s", i, tileLabel(d.Tile(i))))
+			if d.Accepts(i) {
+				attrs += ", shape=doublecircle"
+			}
+			if d.IsEnd(i) {
+				attrs += ", style=filled, fillcolor=lightgray"
+			}
+			fmt.Fprintf(bw, "\tn%d [%s];\n", i, attrs)
+
+			if child := d.ArcIndex(i); child != 0 {
+				if opts.MaxDepth > 0 && l.depth >= opts.MaxDepth {
+					fmt.Fprintf(bw, "\tn%d_more [label=\"…\", shape=plaintext];\n", i)
+					fmt.Fprintf(bw, "\tn%d -> n%d_more [style=dotted];\n", i, i)
+				} else {
+					fmt.Fprintf(bw, "\tn%d -> n%d;\n", i, child)
+					if !seen[child] {
+						seen[child] = true
+						queue = append(queue, listAt{child, l.depth + 1})
+					}
+				}
+			}
+			if d.IsEnd(i) || int(i)+1 >= numNodes {
+				break
+			}
+			fmt.Fprintf(bw, "\tn%d -> n%d [style=dashed, arrowhead=none];\n", i, i+1)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
@@ -0,0 +1,59 @@
+package kwg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWriteWordList(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "ZZZEXPORT", "CARES", "AB", "CARE", "SCARE")
+	var buf bytes.Buffer
+	is.NoErr(WriteWordList(k, &buf))
+	is.Equal(buf.String(), "AB\nCARE\nCARES\nSCARE\n")
+}
+
+func TestWriteAnnotatedWordList(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "ZZZEXPORT", "AR", "ARE", "CAR", "CARE", "CARED", "CARES", "SCARE")
+	var buf bytes.Buffer
+	is.NoErr(WriteAnnotatedWordList(k, &buf))
+	is.Equal(buf.String(), strings.Join([]string{
+		"c\tAR\te",
+		"c\tARE·\t",
+		"\t·CAR\te",
+		"s\t·CARE·\tds",
+		"\tCARED·\t",
+		"\tCARES·\t",
+		"\t·SCARE\t",
+	}, "\n")+"\n")
+}
+
+func TestWriteDOT(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "ZZZEXPORT", "AB", "ABS", "CAB")
+
+	var buf bytes.Buffer
+	is.NoErr(WriteDOT(k, &buf, DOTOptions{Root: 0}))
+	out := buf.String()
+	is.True(strings.HasPrefix(out, "digraph kwg {\n"))
+	is.True(strings.HasSuffix(out, "}\n"))
+	is.True(strings.Contains(out, "doublecircle"))
+	is.True(strings.Contains(out, "fillcolor=lightgray"))
+	is.True(strings.Contains(out, "style=dashed"))
+	is.True(!strings.Contains(out, "_more"))
+	// Only the DAWG is drawn from the DAWG root, so the only separator is
+	// the root node's own tile.
+	is.Equal(strings.Count(out, `\n^"`), 1)
+
+	buf.Reset()
+	is.NoErr(WriteDOT(k, &buf, DOTOptions{Root: 1, MaxDepth: 2}))
+	out = buf.String()
+	is.True(strings.Count(out, `\n^"`) > 1)
+	is.True(strings.Contains(out, "_more"))
+
+	is.True(WriteDOT(k, &buf, DOTOptions{Root: uint32(len(k.nodes))}) != nil)
+}
@@ -0,0 +1,180 @@
+package kwg
+
+import (
+	"errors"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// ExtensionRow is a row (or column) of the board, as seen by an Extender.
+type ExtensionRow struct {
+	// Tiles has the tile on each square, or 0 if the square is empty.
+	// Blanks on the board are designated (blanked) letters.
+	Tiles []tilemapping.MachineLetter
+	// CrossSets has the letters that can be placed on each empty square,
+	// e.g. as computed by ComputeCrossSet. Occupied squares are ignored.
+	CrossSets []tilemapping.LetterSet
+}
+
+// ExtensionFunc is called by an Extender for every legal placement. The
+// placement covers the squares from leftCol onwards; played-through
+// squares are 0, blanks from the rack are designated (blanked) letters, and
+// tilesPlayed is the number of tiles from the rack. The placement slice is
+// reused: it is only valid during the call.
+type ExtensionFunc func(leftCol int, placement tilemapping.MachineWord, tilesPlayed int)
+
+// An Extender generates placements through an anchor square with the
+// GADDAG: it goes left from the anchor, crosses the separator, and goes
+// right. It is the core of a move generator; it doesn't allocate once its
+// buffer is as long as the longest row it has seen. An Extender is not
+// safe for concurrent use.
+type Extender[T WordGraphConstraint] struct {
+	d     T
+	strip []tilemapping.MachineLetter
+
+	// State of the current Extend call.
+	row         ExtensionRow
+	anchor      int
+	lastAnchor  int
+	rack        *tilemapping.Rack
+	numLetters  tilemapping.MachineLetter
+	tilesPlayed int
+	f           ExtensionFunc
+}
+
+// NewExtender creates an Extender for the GADDAG of d. DAWG-only graphs fail
+// with ErrNoGADDAG, and since cross-sets are LetterSets, wide alphabets
+// aren't supported.
+func NewExtender[T WordGraphConstraint](d T) (*Extender[T], error) {
+	if !graphBase(d).HasGADDAG() {
+		return nil, ErrNoGADDAG
+	}
+	if graphBase(d).wide {
+		return nil, errWideAlphabet
+	}
+	return &Extender[T]{d: d}, nil
+}
+
+// Extend calls f for every placement of tiles from rack on row that goes
+// through the anchor square and forms a word, along with the tiles already
+// on the row. Going left stops before lastAnchor; when every anchor of a
+// row is extended from left to right, passing the previous anchor (or -1
+// for the first one) makes each placement come up exactly once. The rack
+// is used as scratch space and is restored before Extend returns.
+func (e *Extender[T]) Extend(row ExtensionRow, anchor, lastAnchor int, rack *tilemapping.Rack, f ExtensionFunc) error {
+	if len(row.CrossSets) != len(row.Tiles) {
+		return errors.New("row needs one cross-set per square")
+	}
+	if anchor < 0 || anchor >= len(row.Tiles) {
+		return errors.New("anchor is not on the row")
+	}
+	if lastAnchor >= anchor {
+		return errors.New("last anchor must be left of the anchor")
+	}
+	if len(e.strip) < len(row.Tiles) {
+		e.strip = make([]tilemapping.MachineLetter, len(row.Tiles))
+	}
+	e.row = row
+	e.anchor = anchor
+	e.lastAnchor = lastAnchor
+	e.rack = rack
+	e.numLetters = tilemapping.MachineLetter(len(rack.LetArr))
+	e.tilesPlayed = 0
+	e.f = f
+	e.gen(anchor, e.d.ArcIndex(1), anchor, anchor)
+	e.rack = nil
+	e.f = nil
+	e.row = ExtensionRow{}
+	return nil
+}
+
+// gen places a tile on square col, coming from the arc list nodeIdx.
+func (e *Extender[T]) gen(col int, nodeIdx uint32, leftCol, rightCol int) {
+	if nodeIdx == 0 {
+		return
+	}
+	if ml := e.row.Tiles[col]; ml != 0 {
+		raw := uint8(ml.Unblank())
+		for i := nodeIdx; ; i++ {
+			if e.d.Tile(i) == raw {
+				e.goOn(col, 0, e.d.ArcIndex(i), e.d.Accepts(i), leftCol, rightCol)
+				return
+			}
+			if e.d.IsEnd(i) {
+				return
+			}
+		}
+	}
+	if e.rack.NumTiles() == 0 {
+		return
+	}
+	crossSet := e.row.CrossSets[col]
+	haveBlank := e.rack.LetArr[0] > 0
+	for i := nodeIdx; ; i++ {
+		ml := tilemapping.MachineLetter(e.d.Tile(i))
+		if ml != 0 && ml < e.numLetters && crossSet&(1<<ml) != 0 {
+			if e.rack.LetArr[ml] > 0 {
+				e.rack.Take(ml)
+				e.tilesPlayed++
+				e.goOn(col, ml, e.d.ArcIndex(i), e.d.Accepts(i), leftCol, rightCol)
+				e.tilesPlayed--
+				e.rack.Add(ml)
+			}
+			if haveBlank {
+				e.rack.Take(0)
+				e.tilesPlayed++
+				e.goOn(col, ml.Blank(), e.d.ArcIndex(i), e.d.Accepts(i), leftCol, rightCol)
+				e.tilesPlayed--
+				e.rack.Add(0)
+			}
+		}
+		if e.d.IsEnd(i) {
+			return
+		}
+	}
+}
+
+// goOn records the tile ml (0 for a played-through tile) on square col,
+// reports the placement if it ends a word, and keeps extending.
+func (e *Extender[T]) goOn(col int, ml tilemapping.MachineLetter, nodeIdx uint32,
+	accepts bool, leftCol, rightCol int) {
+
+	e.strip[col] = ml
+	last := len(e.row.Tiles) - 1
+	if col <= e.anchor {
+		leftCol = col
+		noTileLeft := col == 0 || e.row.Tiles[col-1] == 0
+		noTileRight := e.anchor == last || e.row.Tiles[e.anchor+1] == 0
+		if accepts && noTileLeft && noTileRight && e.tilesPlayed > 0 {
+			e.f(leftCol, e.strip[leftCol:rightCol+1], e.tilesPlayed)
+		}
+		if nodeIdx == 0 {
+			return
+		}
+		if col > 0 && col-1 != e.lastAnchor {
+			e.gen(col-1, nodeIdx, leftCol, rightCol)
+		}
+		// Cross the separator and go right from the anchor.
+		if noTileLeft && e.anchor < last {
+			e.gen(e.anchor+1, e.separatorArc(nodeIdx), leftCol, rightCol)
+		}
+		return
+	}
+	rightCol = col
+	noTileRight := col == last || e.row.Tiles[col+1] == 0
+	if accepts && noTileRight && e.tilesPlayed > 0 {
+		e.f(leftCol, e.strip[leftCol:rightCol+1], e.tilesPlayed)
+	}
+	if nodeIdx != 0 && col < last {
+		e.gen(col+1, nodeIdx, leftCol, rightCol)
+	}
+}
+
+// separatorArc returns the arc list after the separator in the arc list
+// nodeIdx, or 0. The separator, if any, is the first arc.
+func (e *Extender[T]) separatorArc(nodeIdx uint32) uint32 {
+	if e.d.Tile(nodeIdx) == 0 {
+		return e.d.ArcIndex(nodeIdx)
+	}
+	return 0
+}
@@ -0,0 +1,194 @@
+package kwg
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+var extendTestWords = []string{"AT", "TA", "CAT", "CATS", "SCAT", "ACT", "ACTS", "ART", "ARTS",
+	"RAT", "RATS", "TAR", "TARS", "STAR", "CART", "CARTS", "SCART", "TRACT", "TRACTS", "AR", "CAR", "CARS"}
+
+// bruteForcePlacements finds every placement through anchor by filling the
+// empty squares of every span around it with rack tiles in every way.
+func bruteForcePlacements(k *KWG, row ExtensionRow, anchor int, rack []tilemapping.MachineLetter) []string {
+	found := map[string]bool{}
+	n := len(row.Tiles)
+	numLetters := tilemapping.MachineLetter(k.GetAlphabet().NumLetters())
+	for left := 0; left <= anchor; left++ {
+		for right := anchor; right < n; right++ {
+			if left > 0 && row.Tiles[left-1] != 0 || right < n-1 && row.Tiles[right+1] != 0 {
+				continue
+			}
+			var fill func(col int, placement tilemapping.MachineWord, word tilemapping.MachineWord,
+				left []tilemapping.MachineLetter, played int)
+			fill = func(col int, placement, word tilemapping.MachineWord, rest []tilemapping.MachineLetter, played int) {
+				if col > right {
+					if played > 0 && FindMachineWord(k, unblankedCopy(word)) {
+						found[fmt.Sprint(left, placement)] = true
+					}
+					return
+				}
+				if ml := row.Tiles[col]; ml != 0 {
+					fill(col+1, append(placement, 0), append(word, ml), rest, played)
+					return
+				}
+				for i, r := range rest {
+					others := append(append([]tilemapping.MachineLetter{}, rest[:i]...), rest[i+1:]...)
+					choices := []tilemapping.MachineLetter{r}
+					if r == 0 {
+						choices = nil
+						for ml := tilemapping.MachineLetter(1); ml < numLetters; ml++ {
+							choices = append(choices, ml.Blank())
+						}
+					}
+					for _, c := range choices {
+						if row.CrossSets[col]&(1<<c.Unblank()) == 0 {
+							continue
+						}
+						fill(col+1, append(placement, c), append(word, c), others, played+1)
+					}
+				}
+			}
+			fill(left, nil, nil, rack, 0)
+		}
+	}
+	out := make([]string, 0, len(found))
+	for p := range found {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func extendAll(t *testing.T, e *Extender[*KWG], row ExtensionRow, anchor, lastAnchor int, rack *tilemapping.Rack) []string {
+	t.Helper()
+	out := []string{}
+	err := e.Extend(row, anchor, lastAnchor, rack, func(leftCol int, placement tilemapping.MachineWord, tilesPlayed int) {
+		played := 0
+		for _, ml := range placement {
+			if ml != 0 {
+				played++
+			}
+		}
+		if played != tilesPlayed {
+			t.Errorf("placement %v reports %d tiles played", placement, tilesPlayed)
+		}
+		out = append(out, fmt.Sprint(leftCol, placement))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func testExtensionRow(t *testing.T, k *KWG, tiles string) ExtensionRow {
+	t.Helper()
+	row := ExtensionRow{
+		Tiles:     make([]tilemapping.MachineLetter, len(tiles)),
+		CrossSets: make([]tilemapping.LetterSet, len(tiles)),
+	}
+	for i, c := range tiles {
+		row.CrossSets[i] = ^tilemapping.LetterSet(0)
+		if c != '.' {
+			row.Tiles[i] = testMW(t, k, string(c))[0]
+		}
+	}
+	return row
+}
+
+func TestExtendMatchesBruteForce(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "ZZZEXTEND", extendTestWords...)
+	e, err := NewExtender(k)
+	is.NoErr(err)
+
+	row := testExtensionRow(t, k, "...a....T..")
+	// Only C and S can go on square 1.
+	row.CrossSets[1] = 1<<testMW(t, k, "C")[0] | 1<<testMW(t, k, "S")[0]
+
+	total := 0
+	for _, rackStr := range []string{"CRTS", "CR?"} {
+		rack := tilemapping.RackFromString(rackStr, k.GetAlphabet())
+		for _, anchor := range []int{2, 4, 7, 9} {
+			got := extendAll(t, e, row, anchor, -1, rack)
+			want := bruteForcePlacements(k, row, anchor, rack.TilesOn())
+			is.Equal(got, want)
+			total += len(got)
+			is.Equal(rack.String(), tilemapping.RackFromString(rackStr, k.GetAlphabet()).String())
+		}
+	}
+	is.True(total > 50)
+}
+
+func TestExtendEachPlacementOnce(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "ZZZEXTEND", extendTestWords...)
+	e, err := NewExtender(k)
+	is.NoErr(err)
+	row := testExtensionRow(t, k, "...A.....")
+	rack := tilemapping.RackFromString("CRTS", k.GetAlphabet())
+
+	seen := map[string]bool{}
+	want := map[string]bool{}
+	lastAnchor := -1
+	for _, anchor := range []int{2, 4} {
+		for _, p := range extendAll(t, e, row, anchor, lastAnchor, rack) {
+			is.True(!seen[p])
+			seen[p] = true
+		}
+		for _, p := range bruteForcePlacements(k, row, anchor, rack.TilesOn()) {
+			want[p] = true
+		}
+		lastAnchor = anchor
+	}
+	is.Equal(seen, want)
+	is.True(seen["2 [3 0 20]"]) // CAT
+}
+
+func TestExtendErrors(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "ZZZEXTEND", extendTestWords...)
+	e, err := NewExtender(k)
+	is.NoErr(err)
+	rack := tilemapping.RackFromString("CAT", k.GetAlphabet())
+	noop := func(int, tilemapping.MachineWord, int) {}
+	row := testExtensionRow(t, k, "....")
+	is.True(e.Extend(row, 4, -1, rack, noop) != nil)
+	is.True(e.Extend(row, 2, 2, rack, noop) != nil)
+	row.CrossSets = row.CrossSets[:3]
+	is.True(e.Extend(row, 2, -1, rack, noop) != nil)
+
+	d, err := BuildKWG(k.GetAlphabet(), "ZZZEXTENDDAWG", extendTestWords, GraphKindDAWG)
+	is.NoErr(err)
+	_, err = NewExtender(d)
+	is.Equal(err, ErrNoGADDAG)
+}
+
+func TestExtendDoesNotAllocate(t *testing.T) {
+	k := buildTestKWG(t, "ZZZEXTEND", extendTestWords...)
+	e, err := NewExtender(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := testExtensionRow(t, k, "...A....T..")
+	rack := tilemapping.RackFromString("CRS?", k.GetAlphabet())
+	count := 0
+	f := func(int, tilemapping.MachineWord, int) { count++ }
+	allocs := testing.AllocsPerRun(10, func() {
+		if err := e.Extend(row, 4, -1, rack, f); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Extend allocated %v times per run", allocs)
+	}
+	if count == 0 {
+		t.Error("no placements found")
+	}
+}
@@ -0,0 +1,82 @@
+package kwg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// fingerprintCache holds a graph's fingerprint. Like wordCountIndex, it is
+// shared by copies of the graph, so the fingerprint is only computed once.
+type fingerprintCache struct {
+	sync.Mutex
+	fingerprint string
+}
+
+// Fingerprint identifies the lexicon in the graph: it is the hex-encoded
+// SHA-256 hash of the graph's word list as written by WriteWordList, so it
+// can be checked with sha256sum. It only depends on the words and how they
+// are spelled, not on the graph's layout or kind, so a KWG, a KBWG and a
+// DAWG-only graph of the same lexicon have the same fingerprint. It is
+// computed on first use, and is empty if the graph has no alphabet.
+func (k *KWG) Fingerprint() string {
+	return graphFingerprint(k)
+}
+
+// Fingerprint identifies the lexicon in the graph. See KWG.Fingerprint.
+func (k *KBWG) Fingerprint() string {
+	return graphFingerprint(k)
+}
+
+func graphFingerprint[T WordGraphConstraint](d T) string {
+	f := graphBase(d).fingerprint
+	f.Lock()
+	defer f.Unlock()
+	if f.fingerprint == "" {
+		h := sha256.New()
+		if err := WriteWordList(d, h); err != nil {
+			return ""
+		}
+		f.fingerprint = hex.EncodeToString(h.Sum(nil))
+	}
+	return f.fingerprint
+}
+
+// FingerprintMismatchError is returned when a graph loaded with
+// WithFingerprint has a different fingerprint.
+type FingerprintMismatchError struct {
+	LexiconName string
+	Expected    string
+	Actual      string
+}
+
+func (e *FingerprintMismatchError) Error() string {
+	return fmt.Sprintf("lexicon %s has fingerprint %s, expected %s",
+		e.LexiconName, e.Actual, e.Expected)
+}
+
+// WithFingerprint makes loading fail with a *FingerprintMismatchError
+// unless the graph's Fingerprint is the given one, e.g. to make sure a
+// client and a server use the same lexicon. Since it doesn't change the
+// loaded graph, it isn't part of the cache key: a graph already in the
+// cache is checked too.
+func WithFingerprint(fingerprint string) LoadOption {
+	return func(o *loadOpts) { o.fingerprint = fingerprint }
+}
+
+// checkFingerprint checks a loaded graph against the WithFingerprint option.
+func checkFingerprint[T WordGraphConstraint](d T, o loadOpts) error {
+	if o.fingerprint == "" {
+		return nil
+	}
+	if actual := graphFingerprint(d); !strings.EqualFold(actual, o.fingerprint) {
+		return &FingerprintMismatchError{
+			LexiconName: d.LexiconName(),
+			Expected:    o.fingerprint,
+			Actual:      actual,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package kwg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestFingerprintIgnoresLayout(t *testing.T) {
+	is := is.New(t)
+	tm := testEnglishLD(t).TileMapping()
+	words := []string{"CARE", "ACRE", "RACE", "CAR", "ARC"}
+
+	full, err := BuildKWG(tm, "ZZZFP", words, GraphKindDAWGAndGADDAG)
+	is.NoErr(err)
+	dawg, err := BuildKWG(tm, "ZZZFP", []string{"RACE", "ARC", "ACRE", "CAR", "CARE", "CAR"}, GraphKindDAWG)
+	is.NoErr(err)
+	buf := &bytes.Buffer{}
+	is.NoErr(binary.Write(buf, binary.LittleEndian, toKBWGNodes(full.Nodes())))
+	kb, err := ScanKBWG(buf, buf.Len())
+	is.NoErr(err)
+	kb.setAlphabet(tm)
+
+	fp := full.Fingerprint()
+	is.Equal(len(fp), 64)
+	is.Equal(dawg.Fingerprint(), fp)
+	is.Equal(kb.Fingerprint(), fp)
+
+	// It's the hash of the word list.
+	list := &strings.Builder{}
+	is.NoErr(WriteWordList(full, list))
+	sum := sha256.Sum256([]byte(list.String()))
+	is.Equal(fp, hex.EncodeToString(sum[:]))
+
+	other, err := BuildKWG(tm, "ZZZFP", append(words, "ACE"), GraphKindDAWGAndGADDAG)
+	is.NoErr(err)
+	is.True(other.Fingerprint() != fp)
+
+	// A graph without an alphabet has none.
+	is.Equal(newKWG(full.Nodes()).Fingerprint(), "")
+}
+
+func TestLoadWithFingerprint(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	k := writeTestLexicon(t, cfg, "ZZZLEX60", ".kwg", "AB", "BA", "ABBA")
+	path := lexiconFilePath(cfg, "ZZZLEX60", ".kwg")
+	fp := k.Fingerprint()
+
+	g, err := LoadKWG(cfg, path, WithDistribution(fakeDistOne), WithFingerprint(strings.ToUpper(fp)))
+	is.NoErr(err)
+	is.Equal(g.Fingerprint(), fp)
+
+	_, err = LoadKWG(cfg, path, WithDistribution(fakeDistOne), WithFingerprint("abc"))
+	var mismatch *FingerprintMismatchError
+	is.True(errors.As(err, &mismatch))
+	is.Equal(*mismatch, FingerprintMismatchError{LexiconName: "ZZZLEX60", Expected: "abc", Actual: fp})
+
+	// Cached graphs are checked too.
+	_, err = GetKWG(cfg, "ZZZLEX60", WithDistribution(fakeDistOne), WithFingerprint(fp))
+	is.NoErr(err)
+	_, err = GetKWG(cfg, "ZZZLEX60", WithDistribution(fakeDistOne), WithFingerprint("abc"))
+	is.True(errors.As(err, &mismatch))
+}
@@ -0,0 +1,163 @@
+package kwg
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// GridCell is the position of a cell in a letter grid.
+type GridCell struct {
+	Row, Col int
+}
+
+// GridWord is a word found in a letter grid, along with the cells that
+// trace it (one per tile) and its score.
+type GridWord struct {
+	Word  tilemapping.MachineWord
+	Path  []GridCell
+	Score int
+}
+
+// GridScoreFunc scores a word found in a letter grid.
+type GridScoreFunc func(word tilemapping.MachineWord) int
+
+// GridSolverOptions configures SolveGrid. The zero value finds words of any
+// length (2 tiles or more, like everything else in a KWG) and scores nothing.
+type GridSolverOptions struct {
+	// MinLength is the minimum word length, in tiles. A "QU" cell is a
+	// single tile.
+	MinLength int
+	// Score, if set, is used to fill in GridWord.Score.
+	Score GridScoreFunc
+}
+
+// BoggleScoring returns a GridScoreFunc implementing the classic Boggle
+// scoring table. Word length is counted in letters rather than tiles, so a
+// "QU" cell counts as two letters.
+func BoggleScoring(alph *tilemapping.TileMapping) GridScoreFunc {
+	return func(word tilemapping.MachineWord) int {
+		n := 0
+		for _, ml := range word {
+			n += utf8.RuneCountInString(alph.Letter(ml.Unblank()))
+		}
+		switch {
+		case n < 3:
+			return 0
+		case n <= 4:
+			return 1
+		case n == 5:
+			return 2
+		case n == 6:
+			return 3
+		case n == 7:
+			return 5
+		default:
+			return 11
+		}
+	}
+}
+
+// SolveGrid returns every word that can be traced in the grid by moving
+// between horizontally, vertically or diagonally adjacent cells, without
+// using a cell twice. Cells holding 0 are treated as holes. Each word is
+// reported once, with the first path found for it, and the results are
+// sorted by word.
+func SolveGrid[T WordGraphConstraint](d T, grid [][]tilemapping.MachineLetter, opts GridSolverOptions) ([]GridWord, error) {
+	if len(grid) == 0 {
+		return []GridWord{}, nil
+	}
+	cols := len(grid[0])
+	for _, row := range grid {
+		if len(row) != cols {
+			return nil, errors.New("grid must be rectangular")
+		}
+	}
+	gs := &gridSolver[T]{
+		d:       d,
+		grid:    grid,
+		opts:    opts,
+		used:    make([][]bool, len(grid)),
+		found:   map[string]bool{},
+		results: []GridWord{},
+	}
+	for r := range grid {
+		gs.used[r] = make([]bool, cols)
+	}
+	root := d.ArcIndex(0)
+	for r := range grid {
+		for c := range grid[r] {
+			gs.visit(r, c, root)
+		}
+	}
+	sort.Slice(gs.results, func(i, j int) bool {
+		return bytes.Compare(gs.results[i].Word.ToByteArr(), gs.results[j].Word.ToByteArr()) < 0
+	})
+	return gs.results, nil
+}
+
+type gridSolver[T WordGraphConstraint] struct {
+	d       T
+	grid    [][]tilemapping.MachineLetter
+	opts    GridSolverOptions
+	used    [][]bool
+	word    tilemapping.MachineWord
+	path    []GridCell
+	found   map[string]bool
+	results []GridWord
+}
+
+// visit extends the current path onto cell (r, c). nodeIdx is the arc list
+// the cell's letter has to be found in.
+func (gs *gridSolver[T]) visit(r, c int, nodeIdx uint32) {
+	ml := gs.grid[r][c].Unblank()
+	if nodeIdx == 0 || ml == 0 || gs.used[r][c] {
+		return
+	}
+	next := gs.d.NextNodeIdx(nodeIdx, ml)
+	accepts := gs.d.InLetterSet(ml, nodeIdx)
+	if next == 0 && !accepts {
+		// Nothing in the lexicon starts with this path.
+		return
+	}
+	gs.used[r][c] = true
+	gs.word = append(gs.word, ml)
+	gs.path = append(gs.path, GridCell{Row: r, Col: c})
+
+	if accepts && len(gs.word) >= 2 && len(gs.word) >= gs.opts.MinLength {
+		gs.record()
+	}
+	if next != 0 {
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				nr, nc := r+dr, c+dc
+				if (dr != 0 || dc != 0) && nr >= 0 && nr < len(gs.grid) && nc >= 0 && nc < len(gs.grid[nr]) {
+					gs.visit(nr, nc, next)
+				}
+			}
+		}
+	}
+
+	gs.path = gs.path[:len(gs.path)-1]
+	gs.word = gs.word[:len(gs.word)-1]
+	gs.used[r][c] = false
+}
+
+func (gs *gridSolver[T]) record() {
+	key := string(gs.word.ToByteArr())
+	if gs.found[key] {
+		return
+	}
+	gs.found[key] = true
+	gw := GridWord{
+		Word: append(tilemapping.MachineWord(nil), gs.word...),
+		Path: append([]GridCell(nil), gs.path...),
+	}
+	if gs.opts.Score != nil {
+		gw.Score = gs.opts.Score(gw.Word)
+	}
+	gs.results = append(gs.results, gw)
+}
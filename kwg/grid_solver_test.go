@@ -0,0 +1,97 @@
+package kwg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+func testGrid(t *testing.T, alph *tilemapping.TileMapping, rows ...string) [][]tilemapping.MachineLetter {
+	grid := make([][]tilemapping.MachineLetter, len(rows))
+	for i, r := range rows {
+		row := []tilemapping.MachineLetter{}
+		for _, cell := range strings.Split(r, " ") {
+			ml, err := alph.Val(cell)
+			if err != nil {
+				t.Fatal(err)
+			}
+			row = append(row, ml)
+		}
+		grid[i] = row
+	}
+	return grid
+}
+
+func gridWordStrings(words []GridWord, alph *tilemapping.TileMapping) []string {
+	strs := make([]string, len(words))
+	for i, w := range words {
+		strs[i] = w.Word.UserVisible(alph)
+	}
+	return strs
+}
+
+func TestSolveGrid(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST",
+		"CAT", "CATS", "ACT", "TACT", "SCAT", "TAT", "AT", "TA", "DOG", "CAST")
+	alph := k.GetAlphabet()
+	grid := testGrid(t, alph,
+		"C A",
+		"T S")
+
+	words, err := SolveGrid(k, grid, GridSolverOptions{})
+	is.NoErr(err)
+	// TACT and TAT need the T twice, DOG isn't in the grid.
+	is.Equal(gridWordStrings(words, alph), []string{"ACT", "AT", "CAST", "CAT", "CATS", "SCAT", "TA"})
+	for _, w := range words {
+		is.Equal(len(w.Path), len(w.Word))
+		for i, cell := range w.Path {
+			is.Equal(grid[cell.Row][cell.Col], w.Word[i])
+		}
+	}
+
+	words, err = SolveGrid(k, grid, GridSolverOptions{MinLength: 4, Score: BoggleScoring(alph)})
+	is.NoErr(err)
+	is.Equal(gridWordStrings(words, alph), []string{"CAST", "CATS", "SCAT"})
+	is.Equal(words[0].Score, 1)
+}
+
+func TestSolveGridAdjacency(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "AB", "AC", "AD")
+	alph := k.GetAlphabet()
+	grid := testGrid(t, alph,
+		"A ? C",
+		"? ? ?",
+		"B ? D")
+
+	// Holes (blanks) block paths, and nothing is adjacent across them.
+	words, err := SolveGrid(k, grid, GridSolverOptions{})
+	is.NoErr(err)
+	is.Equal(len(words), 0)
+
+	_, err = SolveGrid(k, [][]tilemapping.MachineLetter{{1, 2}, {3}}, GridSolverOptions{})
+	is.True(err != nil)
+}
+
+func TestSolveGridMultiRuneTiles(t *testing.T) {
+	is := is.New(t)
+	ld, err := tilemapping.ScanLetterDistribution(strings.NewReader(
+		"?,2,0,0\nA,9,1,1\nE,12,1,1\nI,9,1,1\nQU,1,10,0\nT,6,1,0\n"))
+	is.NoErr(err)
+	k := buildTestKWGWithDist(t, ld, "TEST", "QUIT", "QUIET", "QUITE", "TIE", "QUA")
+	alph := k.GetAlphabet()
+	grid := testGrid(t, alph,
+		"QU I",
+		"T E")
+
+	words, err := SolveGrid(k, grid, GridSolverOptions{Score: BoggleScoring(alph)})
+	is.NoErr(err)
+	is.Equal(gridWordStrings(words, alph), []string{"QUIET", "QUIT", "QUITE", "TIE"})
+	// QUIET is four cells but five letters.
+	is.Equal(len(words[0].Path), 4)
+	is.Equal(words[0].Score, 2)
+}
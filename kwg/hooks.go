@@ -1,6 +1,8 @@
 package kwg
 
 import (
+	"github.com/rs/zerolog/log"
+
 	"github.com/domino14/word-golib/tilemapping"
 )
 
@@ -27,7 +29,25 @@ func reverse(w []tilemapping.MachineLetter) []tilemapping.MachineLetter {
 	return wc
 }
 
+// FindHooks returns the letters that hook onto the given word, or nil if
+// the word isn't valid. Front hooks need a GADDAG; on a DAWG-only graph,
+// FindHooks logs an error and returns nil. Use LookupHooks to get the
+// error.
 func FindHooks(kwg *KWG, word []tilemapping.MachineLetter, hooktype int) []tilemapping.MachineLetter {
+	hooks, err := LookupHooks(kwg, word, hooktype)
+	if err != nil {
+		log.Err(err).Msg("find-hooks")
+		return nil
+	}
+	return hooks
+}
+
+// LookupHooks is like FindHooks, but returns ErrNoGADDAG when asked for
+// front hooks on a DAWG-only graph.
+func LookupHooks(kwg *KWG, word []tilemapping.MachineLetter, hooktype int) ([]tilemapping.MachineLetter, error) {
+	if hooktype != BackHooks && !kwg.HasGADDAG() {
+		return nil, ErrNoGADDAG
+	}
 	nodeIdx := kwg.ArcIndex(1)
 	if hooktype == BackHooks {
 		// ArcIndex 0 is the dawg, can search directly.
@@ -42,7 +62,7 @@ func FindHooks(kwg *KWG, word []tilemapping.MachineLetter, hooktype int) []tilem
 	for {
 		if lidx > len(word)-1 {
 			// If we've gone too far the word is not found.
-			return nil
+			return nil, nil
 		}
 		ml := word[lidx]
 		if kwg.Tile(nodeIdx) == uint8(ml) {
@@ -56,7 +76,7 @@ func FindHooks(kwg *KWG, word []tilemapping.MachineLetter, hooktype int) []tilem
 			lidx++
 		} else {
 			if kwg.IsEnd(nodeIdx) {
-				return nil
+				return nil, nil
 			}
 			nodeIdx++
 		}
@@ -73,7 +93,7 @@ func FindHooks(kwg *KWG, word []tilemapping.MachineLetter, hooktype int) []tilem
 		}
 		nodeIdx++
 	}
-	return hooks
+	return hooks, nil
 }
 
 func FindInnerHook(kwg *KWG, word []tilemapping.MachineLetter, hooktype int) bool {
@@ -0,0 +1,177 @@
+package kwg
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/domino14/word-golib/cache"
+	"github.com/domino14/word-golib/config"
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// KLVEncoding is the encoding of the leave values in a KLV file.
+type KLVEncoding int
+
+const (
+	// KLVInt16 is the original KLV encoding: int16 values in 1/256ths of a
+	// point.
+	KLVInt16 KLVEncoding = iota
+	// KLVFloat32 is the KLV2 encoding: float32 values in points.
+	KLVFloat32
+)
+
+// A KLV holds leave values in the wolges KLV/KLV2 format: a KWG whose DAWG
+// holds every leave as an alphagram (blank first, as tile 0), followed by a
+// table of values indexed by the leave's word index in that DAWG.
+//
+// File layout (all integers little-endian):
+//
+//	u32 number of KWG nodes, followed by the nodes
+//	u32 number of leaves, followed by one value per leave, either as an
+//	    int16 (KLV) or a float32 (KLV2)
+//
+// The encoding is detected from the size of the value table.
+type KLV struct {
+	kwg         *KWG
+	leaveValues []float32
+	encoding    KLVEncoding
+	name        string
+}
+
+// maxInlineLeave is the longest leave LeaveValue sorts without allocating.
+const maxInlineLeave = 16
+
+// ScanKLV reads a KLV or KLV2 from a reader.
+func ScanKLV(data io.Reader, filesize int) (*KLV, error) {
+	var numNodes uint32
+	if err := binary.Read(data, binary.LittleEndian, &numNodes); err != nil {
+		return nil, err
+	}
+	if uint64(numNodes)*4+8 > uint64(filesize) {
+		return nil, errors.New("klv file is truncated")
+	}
+	kwg, err := ScanKWG(data, int(numNodes)*4)
+	if err != nil {
+		return nil, err
+	}
+	var numLeaves uint32
+	if err := binary.Read(data, binary.LittleEndian, &numLeaves); err != nil {
+		return nil, err
+	}
+	remaining := uint64(filesize) - uint64(numNodes)*4 - 8
+
+	klv := &KLV{kwg: kwg, leaveValues: make([]float32, numLeaves)}
+	switch remaining {
+	case uint64(numLeaves) * 4:
+		klv.encoding = KLVFloat32
+		err = binary.Read(data, binary.LittleEndian, klv.leaveValues)
+	case uint64(numLeaves) * 2:
+		klv.encoding = KLVInt16
+		raw := make([]int16, numLeaves)
+		err = binary.Read(data, binary.LittleEndian, raw)
+		for i, v := range raw {
+			klv.leaveValues[i] = float32(v) / 256
+		}
+	default:
+		return nil, errors.New("klv value table has an unexpected size")
+	}
+	if err != nil {
+		return nil, err
+	}
+	kwg.CountWords()
+	log.Debug().Int("num-leaves", int(numLeaves)).Msg("loaded-klv")
+	return klv, nil
+}
+
+// Name returns the name of the KLV, taken from its file name.
+func (k *KLV) Name() string {
+	return k.name
+}
+
+// Encoding returns the encoding the leave values were stored in.
+func (k *KLV) Encoding() KLVEncoding {
+	return k.encoding
+}
+
+// NumLeaves returns the number of leaves that have a value.
+func (k *KLV) NumLeaves() int {
+	return len(k.leaveValues)
+}
+
+// LeaveValue returns the value of the given leave. The leave does not need
+// to be sorted, and may contain blanks either as 0 or as designated blanks.
+// Leaves that aren't in the KLV, including the empty leave, are worth 0.
+func (k *KLV) LeaveValue(leave tilemapping.MachineWord) float64 {
+	if len(leave) == 0 {
+		return 0
+	}
+	var buf [maxInlineLeave]tilemapping.MachineLetter
+	var sorted tilemapping.MachineWord
+	if len(leave) <= maxInlineLeave {
+		sorted = buf[:len(leave)]
+	} else {
+		sorted = make(tilemapping.MachineWord, len(leave))
+	}
+	for i, ml := range leave {
+		if ml.IsBlanked() {
+			ml = 0
+		}
+		sorted[i] = ml
+	}
+	tilemapping.SortMW(sorted)
+	idx := k.kwg.GetWordIndexOf(k.kwg.ArcIndex(0), sorted)
+	if idx < 0 || int(idx) >= len(k.leaveValues) {
+		return 0
+	}
+	return float64(k.leaveValues[idx])
+}
+
+// LoadKLV loads a KLV or KLV2 from a file.
+func LoadKLV(filename string) (*KLV, error) {
+	log.Debug().Msgf("Loading %v ...", filename)
+	file, filesize, err := cache.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	klv, err := ScanKLV(file, filesize)
+	if err != nil {
+		return nil, err
+	}
+	klv.name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	return klv, nil
+}
+
+// CacheLoadFuncKLV is the function that loads a KLV into the global cache
+func CacheLoadFuncKLV(cfg *config.Config, key string) (interface{}, error) {
+	return loadKLVFile(cfg, strings.TrimPrefix(key, CacheKeyPrefixKLV))
+}
+
+// loadKLVFile loads the named leave file, preferring KLV2 over KLV.
+func loadKLVFile(cfg *config.Config, name string) (interface{}, error) {
+	klv, err := LoadKLV(lexiconFilePath(cfg, name, ".klv2"))
+	if errors.Is(err, os.ErrNotExist) {
+		return LoadKLV(lexiconFilePath(cfg, name, ".klv"))
+	}
+	return klv, err
+}
+
+// GetKLV loads a named KLV from the cache or from a file. The leave file
+// lives next to the KWGs, as <name>.klv2 or <name>.klv.
+func GetKLV(cfg *config.Config, name string) (*KLV, error) {
+	obj, err := cache.Load(cfg, CacheKeyPrefixKLV+name, CacheLoadFuncKLV)
+	if err != nil {
+		return nil, err
+	}
+	klv, ok := obj.(*KLV)
+	if !ok {
+		return nil, errors.New("could not convert cached object to KLV")
+	}
+	return klv, nil
+}
@@ -0,0 +1,111 @@
+package kwg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+var klvTestLeaves = map[string]float32{
+	"?":   25.5,
+	"?S":  30.25,
+	"A":   1,
+	"AE":  3.5,
+	"AEQ": -12,
+	"Q":   -7.75,
+	"S":   8,
+	"SS":  5,
+}
+
+// writeTestKLV encodes klvTestLeaves as a KLV2 (float32) or KLV (int16) file.
+func writeTestKLV(t *testing.T, encoding KLVEncoding) []byte {
+	t.Helper()
+	tm := testEnglishLD(t).TileMapping()
+	leaves := []tilemapping.MachineWord{}
+	for l := range klvTestLeaves {
+		mw, err := tilemapping.ToMachineWord(l, tm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaves = append(leaves, mw)
+	}
+	k := newKWG(buildTestNodes(t, leaves, false))
+	values := []float32{}
+	err := forEachWordAt(k, k.ArcIndex(0), nil, func(w tilemapping.MachineWord) error {
+		values = append(values, klvTestLeaves[w.UserVisible(tm)])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, uint32(len(k.nodes)))
+	binary.Write(buf, binary.LittleEndian, k.nodes)
+	binary.Write(buf, binary.LittleEndian, uint32(len(values)))
+	for _, v := range values {
+		if encoding == KLVFloat32 {
+			binary.Write(buf, binary.LittleEndian, v)
+		} else {
+			binary.Write(buf, binary.LittleEndian, int16(v*256))
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestKLVLeaveValue(t *testing.T) {
+	for _, encoding := range []KLVEncoding{KLVFloat32, KLVInt16} {
+		is := is.New(t)
+		data := writeTestKLV(t, encoding)
+		klv, err := ScanKLV(bytes.NewReader(data), len(data))
+		is.NoErr(err)
+		is.Equal(klv.Encoding(), encoding)
+		is.Equal(klv.NumLeaves(), len(klvTestLeaves))
+		tm := testEnglishLD(t).TileMapping()
+
+		for leave, value := range klvTestLeaves {
+			mw, err := tilemapping.ToMachineWord(leave, tm)
+			is.NoErr(err)
+			is.Equal(klv.LeaveValue(mw), float64(value))
+		}
+		// Unsorted, designated blanks, missing and empty leaves.
+		is.Equal(klv.LeaveValue(tilemapping.MachineWord{19, 0}), 30.25)
+		is.Equal(klv.LeaveValue(tilemapping.MachineWord{19 | 0x80}), 25.5)
+		is.Equal(klv.LeaveValue(tilemapping.MachineWord{17, 5, 1}), -12.0)
+		is.Equal(klv.LeaveValue(tilemapping.MachineWord{26}), 0.0)
+		is.Equal(klv.LeaveValue(nil), 0.0)
+	}
+}
+
+func TestScanKLVErrors(t *testing.T) {
+	is := is.New(t)
+	data := writeTestKLV(t, KLVFloat32)
+	_, err := ScanKLV(bytes.NewReader(data[:len(data)-1]), len(data)-1)
+	is.True(err != nil)
+	_, err = ScanKLV(bytes.NewReader([]byte{0xff, 0, 0, 0}), 4)
+	is.True(err != nil)
+}
+
+func TestGetKLV(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	is.NoErr(os.WriteFile(lexiconFilePath(cfg, "zzzleaves01", ".klv"), writeTestKLV(t, KLVInt16), 0644))
+
+	klv, err := GetKLV(cfg, "zzzleaves01")
+	is.NoErr(err)
+	is.Equal(klv.Name(), "zzzleaves01")
+	is.Equal(klv.Encoding(), KLVInt16)
+	is.Equal(klv.LeaveValue(tilemapping.MachineWord{0}), 25.5)
+
+	again, err := GetKLV(cfg, "zzzleaves01")
+	is.NoErr(err)
+	is.True(klv == again)
+
+	_, err = GetKLV(cfg, "zzzleaves02")
+	is.True(err != nil)
+}
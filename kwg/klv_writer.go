@@ -0,0 +1,96 @@
+package kwg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// KLVLeave is a leave and its value, as passed to BuildKLV. The leave is
+// written in user-visible tiles, with ? for the blank, in any order.
+type KLVLeave struct {
+	Leave string
+	Value float64
+}
+
+// BuildKLV builds a KLV from a table of leaves. The leaves are alphagrammed
+// over the given tile mapping, put in a DAWG, and their values are stored in
+// the word-index order that LeaveValue looks them up in.
+func BuildKLV(tm *tilemapping.TileMapping, leaves []KLVLeave) (*KLV, error) {
+	type entry struct {
+		leave tilemapping.MachineWord
+		value float32
+	}
+	entries := make([]entry, len(leaves))
+	for i, l := range leaves {
+		mw, err := tilemapping.ToMachineWord(l.Leave, tm)
+		if err != nil {
+			return nil, err
+		}
+		if len(mw) == 0 {
+			return nil, fmt.Errorf("leave %v is empty", i)
+		}
+		for _, ml := range mw {
+			if ml.IsBlanked() {
+				return nil, fmt.Errorf("leave %q has a designated blank", l.Leave)
+			}
+		}
+		tilemapping.SortMW(mw)
+		entries[i] = entry{leave: mw, value: float32(l.Value)}
+	}
+	// DAWG order is word-index order.
+	sort.Slice(entries, func(i, j int) bool {
+		return compareMachineWords(entries[i].leave, entries[j].leave) < 0
+	})
+	words := make([]tilemapping.MachineWord, len(entries))
+	values := make([]float32, len(entries))
+	for i, e := range entries {
+		if i > 0 && compareMachineWords(e.leave, entries[i-1].leave) == 0 {
+			return nil, fmt.Errorf("leave %v is listed more than once",
+				e.leave.UserVisible(tm))
+		}
+		words[i] = e.leave
+		values[i] = e.value
+	}
+	nodes, err := buildKWGNodes(words, false)
+	if err != nil {
+		return nil, err
+	}
+	kwg := newKWG(nodes)
+	kwg.setAlphabet(tm)
+	kwg.CountWords()
+	return &KLV{kwg: kwg, leaveValues: values, encoding: KLVFloat32}, nil
+}
+
+// Save writes the KLV to w with the given value encoding. KLVInt16 rounds
+// values to the nearest 1/256th of a point.
+func (k *KLV) Save(w io.Writer, encoding KLVEncoding) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(k.kwg.nodes))); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, k.kwg.nodes); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(k.leaveValues))); err != nil {
+		return err
+	}
+	switch encoding {
+	case KLVFloat32:
+		return binary.Write(w, binary.LittleEndian, k.leaveValues)
+	case KLVInt16:
+		raw := make([]int16, len(k.leaveValues))
+		for i, v := range k.leaveValues {
+			scaled := math.Round(float64(v) * 256)
+			if scaled < math.MinInt16 || scaled > math.MaxInt16 {
+				return fmt.Errorf("leave value %v does not fit the int16 encoding", v)
+			}
+			raw[i] = int16(scaled)
+		}
+		return binary.Write(w, binary.LittleEndian, raw)
+	}
+	return fmt.Errorf("unknown klv encoding %v", encoding)
+}
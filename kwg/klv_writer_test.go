@@ -0,0 +1,115 @@
+package kwg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+func TestBuildKLVRoundTrip(t *testing.T) {
+	is := is.New(t)
+	tm := testEnglishLD(t).TileMapping()
+	leaves := []KLVLeave{
+		{"S?", 30.25}, {"?", 25.5}, {"QEA", -12}, {"A", 1}, {"EA", 3.5},
+		{"Q", -7.75}, {"S", 8}, {"SS", 5}, {"QU", 2.001},
+	}
+	klv, err := BuildKLV(tm, leaves)
+	is.NoErr(err)
+	is.Equal(klv.NumLeaves(), len(leaves))
+
+	for _, encoding := range []KLVEncoding{KLVFloat32, KLVInt16} {
+		buf := &bytes.Buffer{}
+		is.NoErr(klv.Save(buf, encoding))
+		loaded, err := ScanKLV(bytes.NewReader(buf.Bytes()), buf.Len())
+		is.NoErr(err)
+		is.Equal(loaded.Encoding(), encoding)
+
+		for _, l := range leaves {
+			mw, err := tilemapping.ToMachineWord(l.Leave, tm)
+			is.NoErr(err)
+			want := klv.LeaveValue(mw)
+			if encoding == KLVInt16 {
+				// Rounded to 1/256ths.
+				is.True(loaded.LeaveValue(mw)-want < 1.0/512 && want-loaded.LeaveValue(mw) < 1.0/512)
+			} else {
+				is.Equal(loaded.LeaveValue(mw), want)
+			}
+			is.Equal(float32(want), float32(l.Value))
+		}
+	}
+}
+
+func TestBuildKLVMatchesHandBuiltKLV(t *testing.T) {
+	is := is.New(t)
+	tm := testEnglishLD(t).TileMapping()
+	leaves := []KLVLeave{}
+	for l, v := range klvTestLeaves {
+		leaves = append(leaves, KLVLeave{l, float64(v)})
+	}
+	klv, err := BuildKLV(tm, leaves)
+	is.NoErr(err)
+	buf := &bytes.Buffer{}
+	is.NoErr(klv.Save(buf, KLVFloat32))
+
+	// The minimized DAWG of klvTestLeaves, written out by hand. The [S] arc
+	// list is shared by ? and S.
+	const (
+		end = KWGNodeIsEndBit
+		acc = KWGNodeAcceptsBit
+	)
+	tile := func(ml uint32) uint32 { return ml << KWGNodeTileShift }
+	nodes := []uint32{
+		end | 5,                  // DAWG root
+		end,                      // no GADDAG
+		tile(19) | acc | end,     // 2: S
+		tile(17) | acc | end,     // 3: Q
+		tile(5) | acc | end | 3,  // 4: E -> Q
+		tile(0) | acc | 2,        // 5: ? -> S
+		tile(1) | acc | 4,        // 6: A -> E
+		tile(17) | acc,           // 7: Q
+		tile(19) | acc | end | 2, // 8: S -> S
+	}
+	// ?, ?S, A, AE, AEQ, Q, S, SS
+	values := []float32{25.5, 30.25, 1, 3.5, -12, -7.75, 8, 5}
+	want := &bytes.Buffer{}
+	binary.Write(want, binary.LittleEndian, uint32(len(nodes)))
+	binary.Write(want, binary.LittleEndian, nodes)
+	binary.Write(want, binary.LittleEndian, uint32(len(values)))
+	binary.Write(want, binary.LittleEndian, values)
+	is.Equal(buf.Bytes(), want.Bytes())
+
+	// It holds the same leaves as the unminimized hand-built KLV.
+	hand := writeTestKLV(t, KLVFloat32)
+	handKLV, err := ScanKLV(bytes.NewReader(hand), len(hand))
+	is.NoErr(err)
+	is.Equal(handKLV.NumLeaves(), klv.NumLeaves())
+	for l, v := range klvTestLeaves {
+		mw, err := tilemapping.ToMachineWord(l, tm)
+		is.NoErr(err)
+		is.Equal(klv.LeaveValue(mw), float64(v))
+		is.Equal(handKLV.LeaveValue(mw), float64(v))
+	}
+	is.True(len(buf.Bytes()) < len(hand))
+}
+
+func TestBuildKLVErrors(t *testing.T) {
+	is := is.New(t)
+	tm := testEnglishLD(t).TileMapping()
+
+	_, err := BuildKLV(tm, []KLVLeave{{"AE", 1}, {"EA", 2}})
+	is.True(err != nil)
+	_, err = BuildKLV(tm, []KLVLeave{{"Ae", 1}})
+	is.True(err != nil)
+	_, err = BuildKLV(tm, []KLVLeave{{"", 1}})
+	is.True(err != nil)
+	_, err = BuildKLV(tm, []KLVLeave{{"A1", 1}})
+	is.True(err != nil)
+
+	klv, err := BuildKLV(tm, []KLVLeave{{"Z", 1000}})
+	is.NoErr(err)
+	is.True(klv.Save(&bytes.Buffer{}, KLVInt16) != nil)
+}
@@ -3,6 +3,7 @@ package kwg
 import (
 	"encoding/binary"
 	"io"
+	"sync"
 
 	"github.com/domino14/word-golib/tilemapping"
 	"github.com/rs/zerolog/log"
@@ -13,10 +14,34 @@ import (
 // Thanks to Andy Kurnia.
 type KWG struct {
 	// Nodes is just a slice of 32-bit elements, the node array.
-	nodes       []uint32
-	alphabet    *tilemapping.TileMapping
+	nodes    []uint32
+	alphabet *tilemapping.TileMapping
+	// wide is set with the alphabet, by setAlphabet.
+	wide        bool
 	lexiconName string
-	wordCounts  []int32
+	kind        GraphKind
+	// accel is only set with WithAccelerationTables.
+	accel *nodeAccel
+	// prune is only set with WithPruningTables.
+	prune []pruneInfo
+	// wordCounts is shared by copies of this KWG (e.g. a Lexicon made from
+	// it), so the index is only ever built once.
+	wordCounts *wordCountIndex
+	// fingerprint is shared the same way.
+	fingerprint *fingerprintCache
+}
+
+// wordCountIndex holds the number of words in the subtree rooted at each
+// node. It is built at most once, either by counting or from a sidecar file.
+type wordCountIndex struct {
+	once   sync.Once
+	counts []int32
+}
+
+func newKWG(nodes []uint32) *KWG {
+	k := &KWG{nodes: nodes, wordCounts: &wordCountIndex{}, fingerprint: &fingerprintCache{}}
+	k.kind = detectGraphKind(k, len(nodes))
+	return k
 }
 
 func ScanKWG(data io.Reader, filesize int) (*KWG, error) {
@@ -27,10 +52,15 @@ func ScanKWG(data io.Reader, filesize int) (*KWG, error) {
 	}
 
 	log.Debug().Int("num-nodes", len(nodes)).Msg("loaded-kwg")
-	return &KWG{nodes: nodes}, nil
+	return newKWG(nodes), nil
 }
 
+// GetRootNodeIndex returns the index of the GADDAG's root arc list, or of
+// the DAWG's if the graph is DAWG-only.
 func (k *KWG) GetRootNodeIndex() uint32 {
+	if k.kind == GraphKindDAWG {
+		return k.ArcIndex(0)
+	}
 	return k.ArcIndex(1) // (1) for a GADDAG, (0) for a DAWG
 }
 
@@ -38,11 +68,26 @@ func (k KWG) GetAlphabet() *tilemapping.TileMapping {
 	return k.alphabet
 }
 
+func (k *KWG) setAlphabet(tm *tilemapping.TileMapping) {
+	k.alphabet = tm
+	k.wide = tm != nil && tm.IsWide()
+}
+
+// IsWide returns whether the graph's alphabet is wide (see
+// tilemapping.TileMapping.IsWide). Wide graphs need the wide LetterSet
+// APIs, such as GetWideLetterSet and ComputeWideCrossSet.
+func (k *KWG) IsWide() bool {
+	return k.wide
+}
+
 func (k *KWG) LexiconName() string {
 	return k.lexiconName
 }
 
 func (k *KWG) NextNodeIdx(nodeIdx uint32, letter tilemapping.MachineLetter) uint32 {
+	if k.accel != nil {
+		return k.accel.nextNodeIdx(k, nodeIdx, letter)
+	}
 	for i := nodeIdx; ; i++ {
 		if k.Tile(i) == uint8(letter) {
 			return k.ArcIndex(i)
@@ -55,6 +100,9 @@ func (k *KWG) NextNodeIdx(nodeIdx uint32, letter tilemapping.MachineLetter) uint
 
 func (k *KWG) InLetterSet(letter tilemapping.MachineLetter, nodeIdx uint32) bool {
 	letter = letter.Unblank()
+	if k.accel != nil {
+		return k.accel.accepts[nodeIdx]&(1<<letter) != 0
+	}
 	for i := nodeIdx; ; i++ {
 		if k.Tile(i) == uint8(letter) {
 			return k.Accepts(i)
@@ -65,7 +113,13 @@ func (k *KWG) InLetterSet(letter tilemapping.MachineLetter, nodeIdx uint32) bool
 	}
 }
 
+// GetLetterSet returns the letters that end a word at the arc list
+// starting at nodeIdx. A LetterSet only holds the first 64 letters, so
+// graphs with a wide alphabet (see IsWide) must use GetWideLetterSet.
 func (k *KWG) GetLetterSet(nodeIdx uint32) tilemapping.LetterSet {
+	if k.accel != nil {
+		return k.accel.accepts[nodeIdx]
+	}
 	var ls tilemapping.LetterSet
 	for i := nodeIdx; ; i++ {
 		t := k.Tile(i)
@@ -79,6 +133,12 @@ func (k *KWG) GetLetterSet(nodeIdx uint32) tilemapping.LetterSet {
 	return ls
 }
 
+// GetWideLetterSet is GetLetterSet for alphabets of any size, including
+// wide ones.
+func (k *KWG) GetWideLetterSet(nodeIdx uint32) tilemapping.WideLetterSet {
+	return wideLetterSetAt(k, nodeIdx)
+}
+
 func (k *KWG) IsEnd(nodeIdx uint32) bool {
 	return k.nodes[nodeIdx]&0x400000 != 0
 }
@@ -102,6 +162,15 @@ func (k *KWG) Tile(nodeIdx uint32) uint8 {
 //	bit   23     accepts flag (1 = path through this node spells a word)
 //	bits 24..31  tile letter (1..MAX_ALPHABET; 0 is unused)
 //
+// The 8-bit tile field fits every tile of a wide alphabet (see
+// tilemapping.MaxWideAlphabetSize), so wide alphabets use this same layout
+// rather than a node format of their own; a KBWG, whose tile field is only
+// 6 bits, fails to load with a wide alphabet. Only the uint64 LetterSet
+// APIs are limited to normal alphabets: ComputeCrossSet, NewExtender and
+// the anagrammer return an error on a wide graph (see KWG.IsWide), and
+// GetLetterSet must not be used on one; GetWideLetterSet and
+// ComputeWideCrossSet handle every alphabet.
+//
 // These constants are exported so a caller that has obtained the
 // underlying nodes slice via [KWG.Nodes] can decode each node
 // directly without having to call Tile/IsEnd/Accepts/ArcIndex four
@@ -154,15 +223,15 @@ func (k *KWG) Nodes() []uint32 {
 }
 
 // I have no idea what is going on in these functions. See wolges kwg.rs
-func (k *KWG) countWordsAt(p uint32) int {
-	if p >= uint32(len(k.wordCounts)) {
+func (k *KWG) countWordsAt(counts []int32, p uint32) int {
+	if p >= uint32(len(counts)) {
 		return 0
 	}
-	if k.wordCounts[p] == -1 {
+	if counts[p] == -1 {
 		panic("unexpected -1")
 	}
-	if k.wordCounts[p] == 0 {
-		k.wordCounts[p] = -1
+	if counts[p] == 0 {
+		counts[p] = -1
 
 		a := 0
 		if k.Accepts(p) {
@@ -170,43 +239,74 @@ func (k *KWG) countWordsAt(p uint32) int {
 		}
 		b := 0
 		if k.ArcIndex(p) != 0 {
-			b = k.countWordsAt(k.ArcIndex(p))
+			b = k.countWordsAt(counts, k.ArcIndex(p))
 		}
 		c := 0
 		if !k.IsEnd(p) {
-			c = k.countWordsAt(p + 1)
+			c = k.countWordsAt(counts, p+1)
 		}
-		k.wordCounts[p] = int32(a + b + c)
+		counts[p] = int32(a + b + c)
+	}
+	return int(counts[p])
+}
+
+func (k *KWG) computeWordCounts() []int32 {
+	counts := make([]int32, len(k.nodes))
+	for p := len(counts) - 1; p >= 0; p-- {
+		k.countWordsAt(counts, uint32(p))
+	}
+	return counts
+}
+
+// wordCountSlice returns the word-count index, building it if needed. It is
+// safe to call concurrently.
+func (k *KWG) wordCountSlice() []int32 {
+	if k.wordCounts == nil {
+		// Only KWGs not made by this package end up here; there is nowhere
+		// to keep the index, so count every time.
+		return k.computeWordCounts()
 	}
-	return int(k.wordCounts[p])
+	k.wordCounts.once.Do(func() {
+		k.wordCounts.counts = k.computeWordCounts()
+	})
+	return k.wordCounts.counts
 }
 
 // WordCountAt returns the number of words in the subtree rooted at nodeIdx.
-// The caller must ensure CountWords has been called before using this method.
+// The word-count index is built on first use if CountWords hasn't been
+// called yet.
 func (k *KWG) WordCountAt(nodeIdx uint32) int32 {
-	return k.wordCounts[nodeIdx]
+	return k.wordCountSlice()[nodeIdx]
 }
 
+// WordCounts returns a read-only view of the word-count index, building it
+// if needed.
+func (k *KWG) WordCounts() WordCountIndex {
+	return WordCountIndex{counts: k.wordCountSlice()}
+}
+
+// CountWords builds the word-count index used by GetWordIndexOf and
+// WordCountAt. It is optional, since the index is built on first use, but
+// lets callers pay the cost up front. The index is only ever built once, so
+// it is safe to call CountWords concurrently, and repeatedly.
 func (k *KWG) CountWords() {
-	k.wordCounts = make([]int32, len(k.nodes))
-	for p := len(k.wordCounts) - 1; p >= 0; p-- {
-		k.countWordsAt(uint32(p))
-	}
+	k.wordCountSlice()
 }
 
 func (k *KWG) GetWordIndexOf(nodeIdx uint32, letters tilemapping.MachineWord) int32 {
+	counts := k.wordCountSlice()
 	idx := int32(0)
 	lidx := 0
 
 	for nodeIdx != 0 {
-		idx += k.wordCounts[nodeIdx]
+		idx += counts[nodeIdx]
 		for k.Tile(nodeIdx) != uint8(letters[lidx]) {
 			if k.IsEnd(nodeIdx) {
 				return -1
 			}
 			nodeIdx++
 		}
-		idx -= k.wordCounts[nodeIdx]
+		idx -= counts[nodeIdx]
 		lidx++
 		if lidx > len(letters)-1 {
 			if k.Accepts(nodeIdx) {
@@ -231,6 +331,15 @@ type KBWG struct {
 	KWG
 }
 
+// GetRootNodeIndex returns the index of the GADDAG's root arc list, or of
+// the DAWG's if the graph is DAWG-only.
+func (k *KBWG) GetRootNodeIndex() uint32 {
+	if k.kind == GraphKindDAWG {
+		return k.ArcIndex(0)
+	}
+	return k.ArcIndex(1)
+}
+
 // Override the Tile method for KBWG
 func (k *KBWG) Tile(nodeIdx uint32) uint8 {
 	return uint8(k.nodes[nodeIdx]) & 0x3f
@@ -255,5 +364,7 @@ func ScanKBWG(data io.Reader, filesize int) (*KBWG, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &KBWG{KWG: *kwg}, nil
+	kbwg := &KBWG{KWG: *kwg}
+	kbwg.kind = detectGraphKind(kbwg, len(kbwg.nodes))
+	return kbwg, nil
 }
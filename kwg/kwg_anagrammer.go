@@ -1,6 +1,7 @@
 package kwg
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -28,8 +29,19 @@ type KWGAnagrammer /*[T WordGraphConstraint]*/ struct {
 	freq        []uint8
 	blanks      uint8
 	queryLength int
+	// numReal and rackMask track the real tiles left in freq during a
+	// search, for pruning. They are only kept up to date when the graph has
+	// pruning tables.
+	numReal  int
+	rackMask tilemapping.LetterSet
+	// ctx, if set, is checked every ctxCheckInterval nodes of a search, so
+	// that long searches can be cancelled between words.
+	ctx   context.Context
+	nodes int
 }
 
+const ctxCheckInterval = 1024
+
 func (da *KWGAnagrammer) commonInit(kwg *KWG) {
 	alph := kwg.GetAlphabet()
 	numLetters := alph.NumLetters()
@@ -57,10 +69,15 @@ func (da *KWGAnagrammer) InitForString(kwg *KWG, tiles string) error {
 	return da.InitForMachineWord(kwg, mls)
 }
 
+// InitForMachineWord sets the tiles to anagram. It fails if the alphabet is
+// wide, since the anagrammer tracks the rack in a LetterSet.
 func (da *KWGAnagrammer) InitForMachineWord(kwg *KWG, machineTiles tilemapping.MachineWord) error {
+	if kwg.wide {
+		return errWideAlphabet
+	}
+	alph := kwg.GetAlphabet()
 	da.commonInit(kwg)
 	da.queryLength = len(machineTiles)
-	alph := kwg.GetAlphabet()
 	numLetters := alph.NumLetters()
 	for _, v := range machineTiles {
 		if v == 0 {
@@ -74,40 +91,88 @@ func (da *KWGAnagrammer) InitForMachineWord(kwg *KWG, machineTiles tilemapping.M
 	return nil
 }
 
+// search starts an iteration with the tiles currently in freq and blanks.
+func (ka *KWGAnagrammer) search(kwg *KWG, nodeIdx uint32, minLen int, minExact int, f func(tilemapping.MachineWord) error) error {
+	if kwg.wide {
+		return errWideAlphabet
+	}
+	if kwg.prune != nil {
+		ka.numReal = 0
+		ka.rackMask = 0
+		for t, n := range ka.freq {
+			if n > 0 {
+				ka.numReal += int(n)
+				ka.rackMask |= 1 << t
+			}
+		}
+	}
+	return ka.iterate(kwg, nodeIdx, minLen, minExact, f)
+}
+
 // f must not modify the given slice. if f returns error, abort iteration.
 func (ka *KWGAnagrammer) iterate(kwg *KWG, nodeIdx uint32, minLen int, minExact int, f func(tilemapping.MachineWord) error) error {
+	pruning := kwg.prune != nil
+	var c pruneCheck
+	if pruning {
+		c = ka.pruneCheck(minLen, minExact)
+	}
 	for ; ; nodeIdx++ {
+		if ka.ctx != nil {
+			if ka.nodes++; ka.nodes%ctxCheckInterval == 0 {
+				if err := ka.ctx.Err(); err != nil {
+					return err
+				}
+			}
+		}
 		j := kwg.Tile(nodeIdx)
-		if ka.freq[j] > 0 {
+		usable := ka.freq[j] > 0 || ka.blanks > 0
+		// With pruning tables, skip arcs that lead to no word that can be
+		// made with what's left.
+		if usable && pruning {
+			usable = !c.cut(&kwg.prune[nodeIdx], ka.rackMask)
+		}
+		if usable && ka.freq[j] > 0 {
 			ka.freq[j]--
+			if pruning {
+				ka.numReal--
+				if ka.freq[j] == 0 {
+					ka.rackMask &^= 1 << j
+				}
+			}
 			ka.ans = append(ka.ans, tilemapping.MachineLetter(j))
+			var err error
 			if minLen <= 1 && minExact <= 1 && kwg.Accepts(nodeIdx) {
-				if err := f(ka.ans); err != nil {
-					return err
-				}
+				err = f(ka.ans)
 			}
-			if arcIndex := kwg.ArcIndex(nodeIdx); arcIndex != 0 {
-				if err := ka.iterate(kwg, arcIndex, minLen-1, minExact-1, f); err != nil {
-					return err
-				}
+			if arcIndex := kwg.ArcIndex(nodeIdx); err == nil && arcIndex != 0 {
+				err = ka.iterate(kwg, arcIndex, minLen-1, minExact-1, f)
 			}
+			// Put the tile back even on error, so the anagrammer can be
+			// used again.
 			ka.ans = ka.ans[:len(ka.ans)-1]
 			ka.freq[j]++
-		} else if ka.blanks > 0 {
+			if pruning {
+				ka.numReal++
+				ka.rackMask |= 1 << j
+			}
+			if err != nil {
+				return err
+			}
+		} else if usable && ka.blanks > 0 {
 			ka.blanks--
 			ka.ans = append(ka.ans, tilemapping.MachineLetter(j))
+			var err error
 			if minLen <= 1 && minExact <= 0 && kwg.Accepts(nodeIdx) {
-				if err := f(ka.ans); err != nil {
-					return err
-				}
+				err = f(ka.ans)
 			}
-			if arcIndex := kwg.ArcIndex(nodeIdx); arcIndex != 0 {
-				if err := ka.iterate(kwg, arcIndex, minLen-1, minExact, f); err != nil {
-					return err
-				}
+			if arcIndex := kwg.ArcIndex(nodeIdx); err == nil && arcIndex != 0 {
+				err = ka.iterate(kwg, arcIndex, minLen-1, minExact, f)
 			}
 			ka.ans = ka.ans[:len(ka.ans)-1]
 			ka.blanks++
+			if err != nil {
+				return err
+			}
 		}
 		if kwg.IsEnd(nodeIdx) {
 			return nil
@@ -116,18 +181,18 @@ func (ka *KWGAnagrammer) iterate(kwg *KWG, nodeIdx uint32, minLen int, minExact
 }
 
 func (da *KWGAnagrammer) Anagram(dawg *KWG, f func(tilemapping.MachineWord) error) error {
-	return da.iterate(dawg, dawg.ArcIndex(0), da.queryLength, 0, f)
+	return da.search(dawg, dawg.ArcIndex(0), da.queryLength, 0, f)
 }
 
 func (da *KWGAnagrammer) Subanagram(dawg *KWG, f func(tilemapping.MachineWord) error) error {
-	return da.iterate(dawg, dawg.ArcIndex(0), 1, 0, f)
+	return da.search(dawg, dawg.ArcIndex(0), 1, 0, f)
 }
 
 func (da *KWGAnagrammer) Superanagram(dawg *KWG, f func(tilemapping.MachineWord) error) error {
 	minExact := da.queryLength - int(da.blanks)
 	blanks := da.blanks
 	da.blanks = 255
-	err := da.iterate(dawg, dawg.ArcIndex(0), da.queryLength, minExact, f)
+	err := da.search(dawg, dawg.ArcIndex(0), da.queryLength, minExact, f)
 	da.blanks = blanks
 	return err
 }
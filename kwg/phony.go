@@ -0,0 +1,231 @@
+package kwg
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"lukechampine.com/frand"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+const (
+	defaultPhonyOrder       = 3
+	maxPhonyOrder           = 4
+	defaultPhonyMaxAttempts = 1000
+)
+
+// PhonyOptions configures a PhonyGenerator.
+type PhonyOptions struct {
+	// Order is the order of the tile n-gram model; the next tile is picked
+	// based on the Order-1 tiles before it. It defaults to 3 and can be at
+	// most 4.
+	Order int
+	// RejectJumbles also rejects candidates that have a valid anagram, so
+	// that the phony can't be "fixed" by rearranging it.
+	RejectJumbles bool
+	// MaxAttempts is the number of candidates Generate tries before giving
+	// up. It defaults to 1000.
+	MaxAttempts int
+}
+
+// A PhonyGenerator produces plausible-looking non-words for "valid or
+// phony?" quizzes. It learns tile-transition statistics from every word in
+// a KWG and samples candidates from them, rejecting anything that is in the
+// lexicon. It is not threadsafe.
+type PhonyGenerator struct {
+	kwg  *KWG
+	opts PhonyOptions
+	rng  *frand.RNG
+	da   KWGAnagrammer
+	// counts[k] maps a context of k preceding tiles to how often each tile
+	// follows it. Index 0 of a count slice counts word endings.
+	counts     []map[uint32][]uint32
+	numLetters int
+	weights    []uint64
+}
+
+// NewPhonyGenerator learns tile transitions from kwg. Candidates are
+// reproducible: two generators with the same lexicon, options and seed
+// produce the same sequence of phonies.
+func NewPhonyGenerator(kwg *KWG, seed uint64, opts PhonyOptions) (*PhonyGenerator, error) {
+	if opts.Order == 0 {
+		opts.Order = defaultPhonyOrder
+	}
+	if opts.Order < 1 || opts.Order > maxPhonyOrder {
+		return nil, errors.New("phony n-gram order must be between 1 and 4")
+	}
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = defaultPhonyMaxAttempts
+	}
+	var seedBytes [32]byte
+	binary.LittleEndian.PutUint64(seedBytes[:], seed)
+
+	pg := &PhonyGenerator{
+		kwg:        kwg,
+		opts:       opts,
+		rng:        frand.NewCustom(seedBytes[:], 0, 0),
+		counts:     make([]map[uint32][]uint32, opts.Order),
+		numLetters: int(kwg.GetAlphabet().NumLetters()),
+	}
+	for k := range pg.counts {
+		pg.counts[k] = map[uint32][]uint32{}
+	}
+	pg.weights = make([]uint64, pg.numLetters)
+	pg.learn(kwg.ArcIndex(0), make(tilemapping.MachineWord, 0, 16))
+	return pg, nil
+}
+
+// phonyContext packs the last k tiles of word (padded with 0, which never
+// appears inside a word) into a map key.
+func phonyContext(word tilemapping.MachineWord, k int) uint32 {
+	key := uint32(k)
+	for i := len(word) - k; i < len(word); i++ {
+		key <<= 8
+		if i >= 0 {
+			key |= uint32(word[i])
+		}
+	}
+	return key
+}
+
+func (pg *PhonyGenerator) count(word tilemapping.MachineWord, next tilemapping.MachineLetter) {
+	for k := range pg.counts {
+		key := phonyContext(word, k)
+		c, ok := pg.counts[k][key]
+		if !ok {
+			c = make([]uint32, pg.numLetters)
+			pg.counts[k][key] = c
+		}
+		c[next]++
+	}
+}
+
+func (pg *PhonyGenerator) learn(nodeIdx uint32, word tilemapping.MachineWord) {
+	if nodeIdx == 0 {
+		return
+	}
+	for i := nodeIdx; ; i++ {
+		ml := tilemapping.MachineLetter(pg.kwg.Tile(i))
+		pg.count(word, ml)
+		word = append(word, ml)
+		if pg.kwg.Accepts(i) {
+			pg.count(word, 0)
+		}
+		pg.learn(pg.kwg.ArcIndex(i), word)
+		word = word[:len(word)-1]
+		if pg.kwg.IsEnd(i) {
+			return
+		}
+	}
+}
+
+// following returns the transition counts out of the end of word, backing
+// off to shorter contexts when the longest one was never seen.
+func (pg *PhonyGenerator) following(word tilemapping.MachineWord) []uint32 {
+	for k := len(pg.counts) - 1; k >= 0; k-- {
+		if c, ok := pg.counts[k][phonyContext(word, k)]; ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// Generate returns a phony of the given length. If rack is not empty, the
+// phony is made of length tiles taken from it, with blanks standing for any
+// letter; otherwise any tiles may be used. The result never contains blank
+// designations.
+func (pg *PhonyGenerator) Generate(length int, rack tilemapping.MachineWord) (tilemapping.MachineWord, error) {
+	if length < 2 {
+		return nil, errors.New("phony length must be at least 2")
+	}
+	var avail []int
+	if len(rack) > 0 {
+		if len(rack) < length {
+			return nil, errors.New("rack has fewer tiles than the phony length")
+		}
+		avail = make([]int, pg.numLetters)
+		for _, ml := range rack {
+			if int(ml) >= pg.numLetters {
+				return nil, errors.New("rack tile not in alphabet")
+			}
+			avail[ml]++
+		}
+	}
+	left := make([]int, pg.numLetters)
+	word := make(tilemapping.MachineWord, 0, length)
+	for attempt := 0; attempt < pg.opts.MaxAttempts; attempt++ {
+		copy(left, avail)
+		word = word[:0]
+		for len(word) < length {
+			ml, ok := pg.pick(word, length, left, avail != nil)
+			if !ok {
+				break
+			}
+			word = append(word, ml)
+		}
+		if len(word) == length && pg.acceptable(word) {
+			return append(tilemapping.MachineWord(nil), word...), nil
+		}
+	}
+	return nil, errors.New("could not generate a phony")
+}
+
+// pick samples the next tile of a phony. left holds the remaining rack
+// tiles if useRack is set.
+func (pg *PhonyGenerator) pick(word tilemapping.MachineWord, length int, left []int, useRack bool) (tilemapping.MachineLetter, bool) {
+	counts := pg.following(word)
+	last := len(word) == length-1
+	var total uint64
+	for ml := 1; ml < pg.numLetters; ml++ {
+		pg.weights[ml] = 0
+		if useRack && left[ml] == 0 && left[0] == 0 {
+			continue
+		}
+		w := uint64(1)
+		if counts != nil {
+			w += uint64(counts[ml]) * 16
+		}
+		if last {
+			// Favor tiles that words tend to end with here.
+			word = append(word, tilemapping.MachineLetter(ml))
+			if end := pg.following(word); end != nil {
+				w *= uint64(end[0]) + 1
+			}
+			word = word[:len(word)-1]
+		}
+		pg.weights[ml] = w
+		total += w
+	}
+	if total == 0 {
+		return 0, false
+	}
+	r := pg.rng.Uint64n(total)
+	for ml := 1; ml < pg.numLetters; ml++ {
+		if r < pg.weights[ml] {
+			if useRack {
+				if left[ml] > 0 {
+					left[ml]--
+				} else {
+					left[0]--
+				}
+			}
+			return tilemapping.MachineLetter(ml), true
+		}
+		r -= pg.weights[ml]
+	}
+	return 0, false
+}
+
+func (pg *PhonyGenerator) acceptable(word tilemapping.MachineWord) bool {
+	if FindMachineWord(pg.kwg, word) {
+		return false
+	}
+	if pg.opts.RejectJumbles {
+		jumble, err := pg.da.IsValidJumble(pg.kwg, word)
+		if err != nil || jumble {
+			return false
+		}
+	}
+	return true
+}
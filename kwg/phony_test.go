@@ -0,0 +1,106 @@
+package kwg
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+var phonyTestWords = []string{
+	"CARE", "CARES", "CART", "CARTS", "RACE", "RACES", "TRACE", "TRACES",
+	"STARE", "STAR", "STARES", "RATE", "RATES", "TEAR", "TEARS", "SCAR",
+	"CAST", "CASTE", "ACRE", "ACRES", "CRATE", "CRATES", "REACT", "TAR",
+}
+
+func TestPhonyGeneratorReproducible(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", phonyTestWords...)
+
+	pg1, err := NewPhonyGenerator(k, 42, PhonyOptions{})
+	is.NoErr(err)
+	pg2, err := NewPhonyGenerator(k, 42, PhonyOptions{})
+	is.NoErr(err)
+	l := Lexicon{KWG: *k}
+	for i := 0; i < 20; i++ {
+		p1, err := pg1.Generate(5, nil)
+		is.NoErr(err)
+		p2, err := pg2.Generate(5, nil)
+		is.NoErr(err)
+		is.Equal(p1, p2)
+		is.Equal(len(p1), 5)
+		is.True(!l.HasWord(p1))
+	}
+}
+
+func TestPhonyGeneratorRack(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", phonyTestWords...)
+
+	pg, err := NewPhonyGenerator(k, 1, PhonyOptions{})
+	is.NoErr(err)
+	rack := testMW(t, k, "ACERST")
+	for i := 0; i < 20; i++ {
+		p, err := pg.Generate(5, rack)
+		is.NoErr(err)
+		_, err = tilemapping.Leave(rack, p, false)
+		is.NoErr(err) // every tile must come from the rack
+		is.True(!FindMachineWord(k, p))
+	}
+
+	// With a blank, one tile may be any letter.
+	rack = testMW(t, k, "CAR?")
+	for i := 0; i < 20; i++ {
+		p, err := pg.Generate(4, rack)
+		is.NoErr(err)
+		left := map[tilemapping.MachineLetter]int{}
+		for _, ml := range testMW(t, k, "CAR") {
+			left[ml]++
+		}
+		extra := 0
+		for _, ml := range p {
+			is.True(!ml.IsBlanked() && ml != 0)
+			if left[ml] > 0 {
+				left[ml]--
+			} else {
+				extra++
+			}
+		}
+		is.Equal(extra, 1)
+	}
+}
+
+func TestPhonyGeneratorRejectJumbles(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", phonyTestWords...)
+
+	pg, err := NewPhonyGenerator(k, 7, PhonyOptions{RejectJumbles: true, MaxAttempts: 50})
+	is.NoErr(err)
+	// Every arrangement of ACRT is a jumble of CART.
+	_, err = pg.Generate(4, testMW(t, k, "ACRT"))
+	is.True(err != nil)
+
+	da := KWGAnagrammer{}
+	for i := 0; i < 20; i++ {
+		p, err := pg.Generate(4, nil)
+		is.NoErr(err)
+		jumble, err := da.IsValidJumble(k, p)
+		is.NoErr(err)
+		is.True(!jumble)
+	}
+}
+
+func TestPhonyGeneratorErrors(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", phonyTestWords...)
+
+	_, err := NewPhonyGenerator(k, 0, PhonyOptions{Order: 5})
+	is.True(err != nil)
+	pg, err := NewPhonyGenerator(k, 0, PhonyOptions{})
+	is.NoErr(err)
+	_, err = pg.Generate(1, nil)
+	is.True(err != nil)
+	_, err = pg.Generate(5, testMW(t, k, "ABC"))
+	is.True(err != nil)
+}
@@ -0,0 +1,116 @@
+package kwg
+
+import (
+	"context"
+	"errors"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// PhraseAnagramOptions limits the search done by PhraseAnagram. Zero values
+// mean "no limit".
+type PhraseAnagramOptions struct {
+	// MaxWords is the maximum number of words in a phrase.
+	MaxWords int
+	// MinWordLength is the minimum length of every word in a phrase.
+	MinWordLength int
+	// MaxResults stops the search after this many phrases.
+	MaxResults int
+}
+
+var errPhraseLimit = errors.New("phrase result limit reached")
+
+type phraseAnagrammer struct {
+	ctx     context.Context
+	da      *KWGAnagrammer
+	kwg     *KWG
+	opts    PhraseAnagramOptions
+	phrase  []tilemapping.MachineWord
+	results int
+	f       func([]tilemapping.MachineWord) error
+}
+
+// PhraseAnagram splits the tiles the anagrammer was initialized with into
+// phrases of one or more words that use every tile, and calls f for each
+// phrase. Words within a phrase are in canonical (non-decreasing) order, so
+// every multiset of words is reported only once.
+//
+// f must not modify or retain the given slice. If f returns an error, or ctx
+// is done, the search is aborted and that error is returned; the
+// anagrammer's tiles are left as they were either way.
+func (da *KWGAnagrammer) PhraseAnagram(ctx context.Context, kwg *KWG, opts PhraseAnagramOptions,
+	f func([]tilemapping.MachineWord) error) error {
+
+	remaining := int(da.blanks)
+	for _, ct := range da.freq {
+		remaining += int(ct)
+	}
+	if remaining == 0 {
+		return nil
+	}
+	pa := &phraseAnagrammer{ctx: ctx, da: da, kwg: kwg, opts: opts, f: f}
+	da.ctx, da.nodes = ctx, 0
+	defer func() { da.ctx = nil }()
+	err := pa.extend(remaining)
+	if err == errPhraseLimit {
+		return nil
+	}
+	return err
+}
+
+// extend adds one more word to the phrase, using some of the remaining tiles.
+func (pa *phraseAnagrammer) extend(remaining int) error {
+	if pa.opts.MaxWords > 0 && len(pa.phrase) >= pa.opts.MaxWords {
+		return nil
+	}
+	minLen := max(1, pa.opts.MinWordLength)
+	if pa.opts.MaxWords > 0 && len(pa.phrase) == pa.opts.MaxWords-1 {
+		// The last word has to use up everything.
+		minLen = remaining
+	}
+	if remaining < minLen {
+		return nil
+	}
+
+	// The nested search needs its own answer buffer; the outer one is still
+	// in use by the iteration that called us.
+	saved := pa.da.ans
+	pa.da.ans = make(tilemapping.MachineWord, 0, remaining)
+	defer func() { pa.da.ans = saved }()
+
+	return pa.da.search(pa.kwg, pa.kwg.ArcIndex(0), minLen, 0, func(word tilemapping.MachineWord) error {
+		if err := pa.ctx.Err(); err != nil {
+			return err
+		}
+		if len(pa.phrase) > 0 && compareMachineWords(word, pa.phrase[len(pa.phrase)-1]) < 0 {
+			return nil
+		}
+		pa.phrase = append(pa.phrase, append(tilemapping.MachineWord(nil), word...))
+		defer func() { pa.phrase = pa.phrase[:len(pa.phrase)-1] }()
+
+		left := remaining - len(word)
+		if left > 0 {
+			return pa.extend(left)
+		}
+		if err := pa.f(pa.phrase); err != nil {
+			return err
+		}
+		pa.results++
+		if pa.opts.MaxResults > 0 && pa.results >= pa.opts.MaxResults {
+			return errPhraseLimit
+		}
+		return nil
+	})
+}
+
+func compareMachineWords(a, b tilemapping.MachineWord) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
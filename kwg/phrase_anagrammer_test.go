@@ -0,0 +1,132 @@
+package kwg
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+func collectPhrases(t *testing.T, k *KWG, tiles string, opts PhraseAnagramOptions) []string {
+	t.Helper()
+	da := KWGAnagrammer{}
+	if err := da.InitForString(k, tiles); err != nil {
+		t.Fatal(err)
+	}
+	phrases := []string{}
+	err := da.PhraseAnagram(context.Background(), k, opts, func(words []tilemapping.MachineWord) error {
+		phrases = append(phrases, strings.Join(userVisibleWords(words, k.GetAlphabet()), " "))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return phrases
+}
+
+func TestPhraseAnagram(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "DORMITORY", "DIRTY", "ROOM", "DIRT", "MOORY", "ROOMY", "RID", "MY", "ROOT")
+
+	is.Equal(collectPhrases(t, k, "DORMITORY", PhraseAnagramOptions{}), []string{
+		"DIRT MOORY", "DIRT ROOMY", "DIRTY ROOM", "DORMITORY", "MY RID ROOT",
+	})
+	is.Equal(collectPhrases(t, k, "DORMITORY", PhraseAnagramOptions{MaxWords: 2}), []string{
+		"DIRT MOORY", "DIRT ROOMY", "DIRTY ROOM", "DORMITORY",
+	})
+	is.Equal(collectPhrases(t, k, "DORMITORY", PhraseAnagramOptions{MinWordLength: 3}), []string{
+		"DIRT MOORY", "DIRT ROOMY", "DIRTY ROOM", "DORMITORY",
+	})
+	is.Equal(collectPhrases(t, k, "DORMITORY", PhraseAnagramOptions{MaxResults: 1}), []string{
+		"DIRT MOORY",
+	})
+}
+
+func TestPhraseAnagramCanonicalOrder(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "AB", "BA", "ABAB")
+
+	// AB BA and BA AB are the same multiset of words, so only the former is
+	// reported.
+	is.Equal(collectPhrases(t, k, "AABB", PhraseAnagramOptions{}), []string{
+		"AB AB", "AB BA", "ABAB", "BA BA",
+	})
+}
+
+func TestPhraseAnagramBlanks(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "AB", "BA", "XI")
+
+	is.Equal(collectPhrases(t, k, "AB?I", PhraseAnagramOptions{}), []string{
+		"AB XI", "BA XI",
+	})
+}
+
+func TestPhraseAnagramCancel(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "AB", "BA")
+
+	da := KWGAnagrammer{}
+	is.NoErr(da.InitForString(k, "AABB"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := da.PhraseAnagram(ctx, k, PhraseAnagramOptions{}, func([]tilemapping.MachineWord) error {
+		return nil
+	})
+	is.Equal(err, context.Canceled)
+}
+
+func TestPhraseAnagramCancelBetweenWords(t *testing.T) {
+	is := is.New(t)
+	// No word can be made from the rack, so the callback is never called,
+	// but the search visits over a thousand nodes.
+	var words []string
+	for _, a := range "ABCDEFGHIJ" {
+		for _, b := range "ABCDEFGHIJ" {
+			for _, c := range "ABCDEFGHIJ" {
+				words = append(words, string([]rune{a, b, c})+"ZZZZZZ")
+			}
+		}
+	}
+	k := buildTestKWG(t, "TEST", words...)
+
+	da := KWGAnagrammer{}
+	is.NoErr(da.InitForString(k, "?????"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := da.PhraseAnagram(ctx, k, PhraseAnagramOptions{}, func([]tilemapping.MachineWord) error {
+		return nil
+	})
+	is.Equal(err, context.Canceled)
+	is.Equal(da.blanks, uint8(5))
+	is.Equal(len(da.ans), 0)
+	is.NoErr(da.PhraseAnagram(context.Background(), k, PhraseAnagramOptions{},
+		func([]tilemapping.MachineWord) error { return nil }))
+}
+
+func TestPhraseAnagramErrorRestoresTiles(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "AB", "BA")
+
+	da := KWGAnagrammer{}
+	is.NoErr(da.InitForString(k, "AAB?"))
+	freq := append([]uint8(nil), da.freq...)
+	errStop := errors.New("stop")
+	err := da.PhraseAnagram(context.Background(), k, PhraseAnagramOptions{}, func([]tilemapping.MachineWord) error {
+		return errStop
+	})
+	is.Equal(err, errStop)
+	is.Equal(da.freq, freq)
+	is.Equal(da.blanks, uint8(1))
+	// The anagrammer still finds everything.
+	n := 0
+	is.NoErr(da.PhraseAnagram(context.Background(), k, PhraseAnagramOptions{}, func([]tilemapping.MachineWord) error {
+		n++
+		return nil
+	}))
+	is.Equal(n, len(collectPhrases(t, k, "AAB?", PhraseAnagramOptions{})))
+}
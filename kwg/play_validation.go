@@ -0,0 +1,112 @@
+package kwg
+
+import (
+	"errors"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// PlayRule selects how the strings formed by a play are validated.
+type PlayRule int
+
+const (
+	// ExactWordRule is the usual rule: every formed string must be a word.
+	ExactWordRule PlayRule = iota
+	// AnagramRule is the WordSmog rule: every formed string only needs to
+	// be a valid jumble, i.e. some arrangement of its tiles is a word.
+	AnagramRule
+)
+
+// FormedStringResult is the validation of one string formed by a play.
+type FormedStringResult struct {
+	// Formed is the string as it was passed in.
+	Formed tilemapping.MachineWord
+	Valid  bool
+	// Example is a valid arrangement of Formed's tiles, or nil if there is
+	// none. Designated blanks carry over to the same letter in Example.
+	Example tilemapping.MachineWord
+}
+
+// PlayValidation is the result of Lexicon.ValidatePlay.
+type PlayValidation struct {
+	Rule    PlayRule
+	Strings []FormedStringResult
+	Valid   bool
+}
+
+// ValidatePlay validates every string formed by a play under the given
+// rule. A designated blank counts as the letter it was designated as.
+func (l Lexicon) ValidatePlay(formed []tilemapping.MachineWord, rule PlayRule) (*PlayValidation, error) {
+	if len(formed) == 0 {
+		return nil, errors.New("no formed strings to validate")
+	}
+	if rule != ExactWordRule && rule != AnagramRule {
+		return nil, errors.New("unknown play rule")
+	}
+	pv := &PlayValidation{Rule: rule, Strings: make([]FormedStringResult, len(formed)), Valid: true}
+	for i, f := range formed {
+		lookup := unblankedCopy(f)
+		res := FormedStringResult{Formed: f}
+		switch rule {
+		case ExactWordRule:
+			if l.HasWord(lookup) {
+				res.Example = append(tilemapping.MachineWord(nil), f...)
+			}
+		case AnagramRule:
+			anag, err := l.firstAnagram(lookup)
+			if err != nil {
+				return nil, err
+			}
+			if anag != nil {
+				res.Example = redesignateBlanks(anag, f)
+			}
+		}
+		res.Valid = res.Example != nil
+		if !res.Valid {
+			pv.Valid = false
+		}
+		pv.Strings[i] = res
+	}
+	return pv, nil
+}
+
+var errFoundAnagram = errors.New("found anagram")
+
+// firstAnagram returns the first word in DAWG order made of exactly the
+// given (undesignated, blank-free) tiles, or nil if there is none.
+func (l Lexicon) firstAnagram(word tilemapping.MachineWord) (tilemapping.MachineWord, error) {
+	da := DaPool.Get().(*KWGAnagrammer)
+	defer DaPool.Put(da)
+
+	if err := da.InitForMachineWord(&l.KWG, word); err != nil {
+		return nil, err
+	} else if da.blanks > 0 {
+		return nil, errHasBlanks
+	}
+	var found tilemapping.MachineWord
+	err := da.Anagram(&l.KWG, func(w tilemapping.MachineWord) error {
+		found = append(tilemapping.MachineWord(nil), w...)
+		return errFoundAnagram
+	})
+	if err != nil && err != errFoundAnagram {
+		return nil, err
+	}
+	return found, nil
+}
+
+// redesignateBlanks marks one occurrence in word of every letter that was
+// played as a designated blank in formed.
+func redesignateBlanks(word, formed tilemapping.MachineWord) tilemapping.MachineWord {
+	for _, ml := range formed {
+		if !ml.IsBlanked() {
+			continue
+		}
+		for i := range word {
+			if word[i] == ml.Unblank() {
+				word[i] = ml
+				break
+			}
+		}
+	}
+	return word
+}
@@ -0,0 +1,60 @@
+package kwg
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+func TestValidatePlayExactWords(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "CARE", "RACE", "AT")
+	l := Lexicon{KWG: *k}
+	alph := k.GetAlphabet()
+
+	pv, err := l.ValidatePlay([]tilemapping.MachineWord{testMW(t, k, "cARE"), testMW(t, k, "AT")}, ExactWordRule)
+	is.NoErr(err)
+	is.True(pv.Valid)
+	is.Equal(pv.Strings[0].Example.UserVisible(alph), "cARE")
+
+	pv, err = l.ValidatePlay([]tilemapping.MachineWord{testMW(t, k, "ACER"), testMW(t, k, "AT")}, ExactWordRule)
+	is.NoErr(err)
+	is.True(!pv.Valid)
+	is.True(!pv.Strings[0].Valid)
+	is.True(pv.Strings[0].Example == nil)
+	is.True(pv.Strings[1].Valid)
+}
+
+func TestValidatePlayAnagramRule(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "CARE", "RACE", "AT")
+	l := Lexicon{KWG: *k}
+	alph := k.GetAlphabet()
+
+	pv, err := l.ValidatePlay([]tilemapping.MachineWord{testMW(t, k, "EcRA"), testMW(t, k, "TA")}, AnagramRule)
+	is.NoErr(err)
+	is.Equal(pv.Rule, AnagramRule)
+	is.True(pv.Valid)
+	// The designated blank C stays designated in the example.
+	is.Equal(pv.Strings[0].Example.UserVisible(alph), "cARE")
+	is.Equal(pv.Strings[1].Example.UserVisible(alph), "AT")
+
+	pv, err = l.ValidatePlay([]tilemapping.MachineWord{testMW(t, k, "EcRA"), testMW(t, k, "TAT")}, AnagramRule)
+	is.NoErr(err)
+	is.True(!pv.Valid)
+	is.True(pv.Strings[0].Valid)
+	is.True(!pv.Strings[1].Valid)
+}
+
+func TestValidatePlayErrors(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "AT")
+	l := Lexicon{KWG: *k}
+
+	_, err := l.ValidatePlay(nil, AnagramRule)
+	is.True(err != nil)
+	_, err = l.ValidatePlay([]tilemapping.MachineWord{testMW(t, k, "AT")}, PlayRule(7))
+	is.True(err != nil)
+}
@@ -0,0 +1,138 @@
+package kwg
+
+import (
+	"fmt"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// noCompletion is the minimum length of an arc that doesn't lead to a word.
+const noCompletion = 255
+
+// pruneInfo describes the words that go through an arc: the tiles used by
+// at least one of them, and the number of tiles in the shortest and longest
+// of them, counting from (and including) the arc's own tile.
+type pruneInfo struct {
+	tiles  tilemapping.LetterSet
+	minLen uint8
+	maxLen uint8
+}
+
+// merge combines the completions of two sibling arcs.
+func (p pruneInfo) merge(o pruneInfo) pruneInfo {
+	return pruneInfo{
+		tiles:  p.tiles | o.tiles,
+		minLen: min(p.minLen, o.minLen),
+		maxLen: max(p.maxLen, o.maxLen),
+	}
+}
+
+// pruneBuilder computes pruneInfo for every node. A node's arc info depends
+// on the arc list it points to, and an arc list's info on its arcs, so both
+// are memoized.
+type pruneBuilder struct {
+	k    *KWG
+	arcs []pruneInfo
+	// lists[i] merges the arcs from node i to the end of its arc list.
+	lists []pruneInfo
+	done  []bool
+}
+
+func (b *pruneBuilder) list(i uint32) (pruneInfo, error) {
+	if b.done[i] {
+		return b.lists[i], nil
+	}
+	t := b.k.Tile(i)
+	if t >= 64 {
+		return pruneInfo{}, fmt.Errorf("tile %d at node %d does not fit in a letter set", t, i)
+	}
+	arc := pruneInfo{tiles: 1 << t, minLen: noCompletion}
+	if b.k.Accepts(i) {
+		arc.minLen, arc.maxLen = 1, 1
+	}
+	if child := b.k.ArcIndex(i); child != 0 {
+		below, err := b.list(child)
+		if err != nil {
+			return pruneInfo{}, err
+		}
+		if below.minLen != noCompletion {
+			arc.tiles |= below.tiles
+			arc.minLen = min(arc.minLen, below.minLen+1)
+			arc.maxLen = max(arc.maxLen, min(below.maxLen, noCompletion-1)+1)
+		}
+	}
+	if arc.minLen == noCompletion {
+		arc.tiles = 0
+	}
+	b.arcs[i] = arc
+	agg := arc
+	if !b.k.IsEnd(i) {
+		if int(i)+1 >= len(b.k.nodes) {
+			return pruneInfo{}, fmt.Errorf("arc list at node %d runs off the end of the graph", i)
+		}
+		rest, err := b.list(i + 1)
+		if err != nil {
+			return pruneInfo{}, err
+		}
+		agg = agg.merge(rest)
+	}
+	b.lists[i] = agg
+	b.done[i] = true
+	return agg, nil
+}
+
+// buildPruningTables computes, for every arc, which tiles and how many of
+// them the words through it use, so that the anagrammer can skip arcs that
+// can't lead to a word with the tiles it has left. It must be called before
+// the KWG is shared, e.g. at load time with WithPruningTables.
+func (k *KWG) buildPruningTables() error {
+	n := len(k.nodes)
+	b := &pruneBuilder{
+		k:     k,
+		arcs:  make([]pruneInfo, n),
+		lists: make([]pruneInfo, n),
+		done:  make([]bool, n),
+	}
+	for i := range k.nodes {
+		if _, err := b.list(uint32(i)); err != nil {
+			return err
+		}
+	}
+	k.prune = b.arcs
+	return nil
+}
+
+// HasPruningTables returns whether the graph was loaded with
+// WithPruningTables.
+func (k *KWG) HasPruningTables() bool {
+	return k.prune != nil
+}
+
+// pruneCheck holds what the pruning tables are checked against while the
+// anagrammer goes through an arc list; none of it changes along the list.
+type pruneCheck struct {
+	remaining int
+	minLen    int
+	// mustUseAll is set when every real tile left has to be used, so they
+	// all have to be in some word through the arc.
+	mustUseAll bool
+}
+
+// pruneCheck sets up the pruning checks for an arc list, given that at
+// least minLen more tiles must be used, and at least minExact more of them
+// must be real tiles rather than blanks.
+func (da *KWGAnagrammer) pruneCheck(minLen, minExact int) pruneCheck {
+	remaining := da.numReal + int(da.blanks)
+	return pruneCheck{
+		remaining:  remaining,
+		minLen:     minLen,
+		mustUseAll: minLen >= remaining || minExact > 0 && minExact >= da.numReal,
+	}
+}
+
+// cut returns whether no word through the arc with the given info can be
+// completed with the tiles left.
+func (c *pruneCheck) cut(p *pruneInfo, rackMask tilemapping.LetterSet) bool {
+	return int(p.minLen) > c.remaining || int(p.maxLen) < c.minLen ||
+		c.mustUseAll && rackMask&^p.tiles != 0
+}
@@ -0,0 +1,170 @@
+package kwg
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/config"
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// randomTestWords makes n pseudo-random words of 2 to 9 letters, biased
+// toward common letters so that racks have plenty of anagrams.
+func randomTestWords(n int) []string {
+	const letters = "AAAEEEIIOURSTLNDCMPBGHKQXZ"
+	rng := rand.New(rand.NewPCG(1, 2))
+	words := make([]string, n)
+	for i := range words {
+		b := make([]byte, 2+rng.IntN(8))
+		for j := range b {
+			b[j] = letters[rng.IntN(len(letters))]
+		}
+		words[i] = string(b)
+	}
+	return words
+}
+
+func collectAnagrams(t *testing.T, k *KWG, rack string,
+	search func(*KWGAnagrammer, *KWG, func(tilemapping.MachineWord) error) error) []string {
+
+	t.Helper()
+	da := KWGAnagrammer{}
+	if err := da.InitForString(k, rack); err != nil {
+		t.Fatal(err)
+	}
+	out := []string{}
+	err := search(&da, k, func(w tilemapping.MachineWord) error {
+		out = append(out, w.UserVisible(k.GetAlphabet()))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestPruningTablesDoNotChangeResults(t *testing.T) {
+	is := is.New(t)
+	words := append(randomTestWords(3000), extendTestWords...)
+	plain := buildTestKWG(t, "ZZZPRUNE", words...)
+	pruned := buildTestKWG(t, "ZZZPRUNE", words...)
+	is.NoErr(pruned.buildPruningTables())
+	is.True(pruned.HasPruningTables())
+
+	searches := map[string]func(*KWGAnagrammer, *KWG, func(tilemapping.MachineWord) error) error{
+		"anagram":      (*KWGAnagrammer).Anagram,
+		"subanagram":   (*KWGAnagrammer).Subanagram,
+		"superanagram": (*KWGAnagrammer).Superanagram,
+	}
+	racks := []string{"CARTS", "AEIST", "RETINA?", "AE??", "Q???", "??", "ZZZ", "TRACT", "SEAT?R", "DOG"}
+	total := 0
+	for name, search := range searches {
+		for _, rack := range racks {
+			if name == "superanagram" && len(rack) > 4 {
+				continue
+			}
+			want := collectAnagrams(t, plain, rack, search)
+			got := collectAnagrams(t, pruned, rack, search)
+			if len(got) != len(want) {
+				t.Fatalf("%s %s: %d results with pruning, %d without", name, rack, len(got), len(want))
+			}
+			is.Equal(got, want)
+			total += len(got)
+		}
+	}
+	is.True(total > 1000)
+}
+
+func TestPruningTablesLengths(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "ZZZPRUNE", "CAT", "CATS", "CATTLE")
+	is.NoErr(k.buildPruningTables())
+	c := k.ArcIndex(0)
+	is.Equal(k.Tile(c), uint8(3))
+	p := k.prune[c]
+	is.Equal(p.minLen, uint8(3))
+	is.Equal(p.maxLen, uint8(6))
+	is.Equal(p.tiles, tilemapping.LetterSet(1<<1|1<<3|1<<5|1<<12|1<<19|1<<20))
+}
+
+// subanagramNodes returns the number of nodes the anagrammer visits to
+// find the subanagrams of rack.
+func subanagramNodes(t *testing.T, k *KWG, rack string) int {
+	t.Helper()
+	da := KWGAnagrammer{}
+	if err := da.InitForString(k, rack); err != nil {
+		t.Fatal(err)
+	}
+	// The node count is kept while there is a context to check.
+	da.ctx = context.Background()
+	if err := da.Subanagram(k, func(tilemapping.MachineWord) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	return da.nodes
+}
+
+func TestPruningTablesCutNodes(t *testing.T) {
+	is := is.New(t)
+	words := append(randomTestWords(3000), extendTestWords...)
+	plain := buildTestKWG(t, "ZZZPRUNE", words...)
+	pruned := buildTestKWG(t, "ZZZPRUNE", words...)
+	is.NoErr(pruned.buildPruningTables())
+
+	for _, rack := range []string{"QZ???", "XZ??", "Q?", "AEIST"} {
+		is.True(subanagramNodes(t, pruned, rack) <= subanagramNodes(t, plain, rack))
+	}
+	// Blank-heavy racks can't reach the longer words, which are cut.
+	is.True(subanagramNodes(t, pruned, "QZ???") < 3*subanagramNodes(t, plain, "QZ???")/4)
+}
+
+func benchmarkSubanagram(b *testing.B, rack string, opts ...LoadOption) {
+	is := is.New(b)
+	kwg, err := GetKWG(config.DefaultConfig, "CSW21", opts...)
+	is.NoErr(err)
+	da := KWGAnagrammer{}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n := 0
+		is.NoErr(da.InitForString(kwg, rack))
+		is.NoErr(da.Subanagram(kwg, func(tilemapping.MachineWord) error {
+			n++
+			return nil
+		}))
+	}
+}
+
+func BenchmarkSubanagramBlanks(b *testing.B) {
+	b.Run("plain", func(b *testing.B) { benchmarkSubanagram(b, "QZ???") })
+	b.Run("pruned", func(b *testing.B) { benchmarkSubanagram(b, "QZ???", WithPruningTables()) })
+}
+
+// BenchmarkSubanagramBlanksTestGraph is BenchmarkSubanagramBlanks on a
+// graph of random words, which doesn't need a DATA_PATH.
+func BenchmarkSubanagramBlanksTestGraph(b *testing.B) {
+	words := append(randomTestWords(3000), extendTestWords...)
+	plain := buildTestKWG(b, "ZZZPRUNE", words...)
+	pruned := buildTestKWG(b, "ZZZPRUNE", words...)
+	if err := pruned.buildPruningTables(); err != nil {
+		b.Fatal(err)
+	}
+	for _, g := range []struct {
+		name string
+		k    *KWG
+	}{{"plain", plain}, {"pruned", pruned}} {
+		b.Run(g.name, func(b *testing.B) {
+			da := KWGAnagrammer{}
+			for range b.N {
+				if err := da.InitForString(g.k, "QZ???"); err != nil {
+					b.Fatal(err)
+				}
+				if err := da.Subanagram(g.k, func(tilemapping.MachineWord) error { return nil }); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package kwg
+
+import (
+	"strconv"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// LexiconStats describes the words and the graph of a KWG or KBWG. It is
+// meant for dashboards and for sanity-checking newly built files, and
+// marshals to JSON as is.
+type LexiconStats struct {
+	LexiconName string `json:"lexicon_name"`
+	NumWords    int    `json:"num_words"`
+	// WordsByLength maps a word length to the number of words of that length.
+	WordsByLength map[int]int `json:"words_by_length"`
+	// LetterFrequencyByPosition has, for each position in a word, how many
+	// words have each letter there.
+	LetterFrequencyByPosition []map[string]int `json:"letter_frequency_by_position"`
+	// NumNodes is the size of the node array, including the two root nodes.
+	NumNodes int `json:"num_nodes"`
+	// NumDAWGNodes and NumGADDAGNodes count the nodes reachable from each
+	// root. They can add up to less than NumNodes, since shared arc lists
+	// are counted once, and to more, since the DAWG and the GADDAG can
+	// share arc lists.
+	NumDAWGNodes   int `json:"num_dawg_nodes"`
+	NumGADDAGNodes int `json:"num_gaddag_nodes"`
+	// AverageBranchingFactor is the average number of arcs in the DAWG's
+	// arc lists.
+	AverageBranchingFactor float64  `json:"average_branching_factor"`
+	LongestWordLength      int      `json:"longest_word_length"`
+	LongestWords           []string `json:"longest_words"`
+}
+
+// Stats computes statistics about the lexicon in a single walk of the DAWG
+// (and a walk of the GADDAG to count its nodes).
+func (k *KWG) Stats() *LexiconStats {
+	return graphStats(k)
+}
+
+// Stats computes statistics about the lexicon. See KWG.Stats.
+func (k *KBWG) Stats() *LexiconStats {
+	return graphStats(k)
+}
+
+func graphStats[T WordGraphConstraint](d T) *LexiconStats {
+	st := &LexiconStats{
+		LexiconName:               d.LexiconName(),
+		WordsByLength:             map[int]int{},
+		LetterFrequencyByPosition: []map[string]int{},
+		NumNodes:                  len(graphBase(d).nodes),
+		LongestWords:              []string{},
+	}
+	alph := d.GetAlphabet()
+	letterName := func(t uint8) string {
+		if alph == nil {
+			return strconv.Itoa(int(t))
+		}
+		return alph.Letter(tilemapping.MachineLetter(t))
+	}
+
+	seen := map[uint32]bool{}
+	numLists := 0
+	var longest []tilemapping.MachineWord
+	word := make(tilemapping.MachineWord, 0, 16)
+	var walk func(nodeIdx uint32)
+	walk = func(nodeIdx uint32) {
+		if nodeIdx == 0 {
+			return
+		}
+		if !seen[nodeIdx] {
+			seen[nodeIdx] = true
+			numLists++
+			for i := nodeIdx; ; i++ {
+				st.NumDAWGNodes++
+				if d.IsEnd(i) {
+					break
+				}
+			}
+		}
+		for i := nodeIdx; ; i++ {
+			word = append(word, tilemapping.MachineLetter(d.Tile(i)))
+			if d.Accepts(i) {
+				st.NumWords++
+				st.WordsByLength[len(word)]++
+				for len(st.LetterFrequencyByPosition) < len(word) {
+					st.LetterFrequencyByPosition = append(st.LetterFrequencyByPosition, map[string]int{})
+				}
+				for pos, ml := range word {
+					st.LetterFrequencyByPosition[pos][letterName(uint8(ml))]++
+				}
+				if len(word) > st.LongestWordLength {
+					st.LongestWordLength = len(word)
+					longest = longest[:0]
+				}
+				if len(word) == st.LongestWordLength {
+					longest = append(longest, append(tilemapping.MachineWord(nil), word...))
+				}
+			}
+			walk(d.ArcIndex(i))
+			word = word[:len(word)-1]
+			if d.IsEnd(i) {
+				return
+			}
+		}
+	}
+	walk(d.ArcIndex(0))
+	if numLists > 0 {
+		st.AverageBranchingFactor = float64(st.NumDAWGNodes) / float64(numLists)
+	}
+	for _, w := range longest {
+		if alph == nil {
+			st.LongestWords = append(st.LongestWords, strconv.Quote(string(w.ToByteArr())))
+		} else {
+			st.LongestWords = append(st.LongestWords, w.UserVisible(alph))
+		}
+	}
+	if st.NumNodes > 1 {
+		st.NumGADDAGNodes = countReachableNodes(d, d.ArcIndex(1))
+	}
+	return st
+}
+
+// countReachableNodes counts the nodes in the arc lists reachable from the
+// arc list at nodeIdx, counting each list once.
+func countReachableNodes[T WordGraphConstraint](d T, nodeIdx uint32) int {
+	seen := map[uint32]bool{}
+	stack := []uint32{nodeIdx}
+	count := 0
+	for len(stack) > 0 {
+		idx := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if idx == 0 || seen[idx] {
+			continue
+		}
+		seen[idx] = true
+		for i := idx; ; i++ {
+			count++
+			stack = append(stack, d.ArcIndex(i))
+			if d.IsEnd(i) {
+				break
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,45 @@
+package kwg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestStats(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "ZZZSTATS", "AB", "ABS", "CAB", "CABS", "SCAB")
+	st := k.Stats()
+
+	is.Equal(st.LexiconName, "ZZZSTATS")
+	is.Equal(st.NumWords, 5)
+	is.Equal(st.WordsByLength, map[int]int{2: 1, 3: 2, 4: 2})
+	is.Equal(len(st.LetterFrequencyByPosition), 4)
+	is.Equal(st.LetterFrequencyByPosition[0], map[string]int{"A": 2, "C": 2, "S": 1})
+	is.Equal(st.LetterFrequencyByPosition[3], map[string]int{"S": 1, "B": 1})
+	is.Equal(st.LongestWordLength, 4)
+	is.Equal(st.LongestWords, []string{"CABS", "SCAB"})
+
+	is.Equal(st.NumNodes, len(k.nodes))
+	is.Equal(st.NumDAWGNodes, countReachableNodes(k, k.ArcIndex(0)))
+	is.True(st.NumGADDAGNodes > st.NumDAWGNodes)
+	is.True(st.AverageBranchingFactor >= 1)
+
+	bts, err := json.Marshal(st)
+	is.NoErr(err)
+	var back LexiconStats
+	is.NoErr(json.Unmarshal(bts, &back))
+	is.Equal(&back, st)
+}
+
+func TestStatsEmptyLexicon(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "ZZZSTATSEMPTY")
+	st := k.Stats()
+	is.Equal(st.NumWords, 0)
+	is.Equal(st.NumDAWGNodes, 0)
+	is.Equal(st.NumGADDAGNodes, 0)
+	is.Equal(st.AverageBranchingFactor, 0.0)
+	is.Equal(st.LongestWords, []string{})
+}
@@ -0,0 +1,146 @@
+package kwg
+
+import (
+	"errors"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// StemEntry is one row of a stem analysis table: a tile that can be added
+// to the stem, and the words the stem makes with it.
+type StemEntry struct {
+	Letter tilemapping.MachineLetter
+	Words  []tilemapping.MachineWord
+}
+
+// StemAnalysis is the "stem + ?" table for a stem, e.g. SATINE + ?. For a
+// 6-tile stem it lists the 7-letter anagrams; for a 7-tile rack it lists the
+// 8-letter bingos available through each board letter.
+type StemAnalysis struct {
+	Stem tilemapping.MachineWord
+	// Entries has one entry per letter, in alphabet order. Letters that make
+	// no words are only included if they were explicitly asked for (see
+	// AnalyzeThrough); their Words slice is then empty.
+	Entries []StemEntry
+}
+
+// BingoLetters returns the letters that make at least one word with the stem.
+func (sa *StemAnalysis) BingoLetters() []tilemapping.MachineLetter {
+	letters := []tilemapping.MachineLetter{}
+	for _, e := range sa.Entries {
+		if len(e.Words) > 0 {
+			letters = append(letters, e.Letter)
+		}
+	}
+	return letters
+}
+
+// Usefulness returns the number of unseen tiles that make at least one word
+// with the stem. unseen is indexed by MachineLetter, with the blank at 0, like
+// the tile map returned by tilemapping.Bag.PeekMap. Blanks count as long as
+// any letter bingos, since a blank can stand in for that letter.
+func (sa *StemAnalysis) Usefulness(unseen []uint8) int {
+	total := 0
+	for _, e := range sa.Entries {
+		if len(e.Words) > 0 && int(e.Letter) < len(unseen) {
+			total += int(unseen[e.Letter])
+		}
+	}
+	if total > 0 && len(unseen) > 0 {
+		total += int(unseen[0])
+	}
+	return total
+}
+
+var errEmptyStem = errors.New("stem must not be empty")
+
+// AnalyzeStem returns, for every letter of the alphabet that makes a word
+// with the given stem, the anagrams of the stem plus that letter. The stem
+// must not contain blanks.
+func (da *KWGAnagrammer) AnalyzeStem(kwg *KWG, stem tilemapping.MachineWord) (*StemAnalysis, error) {
+	if len(stem) == 0 {
+		return nil, errEmptyStem
+	}
+	query := make(tilemapping.MachineWord, len(stem)+1)
+	copy(query, stem)
+	// The last tile of the query is a blank; whatever it turns into is the
+	// letter added to the stem.
+	if err := da.InitForMachineWord(kwg, query); err != nil {
+		return nil, err
+	} else if da.blanks > 1 {
+		return nil, errHasBlanks
+	}
+	stemFreq := make([]uint8, len(da.freq))
+	copy(stemFreq, da.freq)
+	counts := make([]uint8, len(da.freq))
+	byLetter := make([][]tilemapping.MachineWord, len(da.freq))
+
+	err := da.Anagram(kwg, func(word tilemapping.MachineWord) error {
+		for i := range counts {
+			counts[i] = 0
+		}
+		var added tilemapping.MachineLetter
+		for _, ml := range word {
+			counts[ml]++
+			if counts[ml] > stemFreq[ml] {
+				added = ml
+			}
+		}
+		w := make(tilemapping.MachineWord, len(word))
+		copy(w, word)
+		byLetter[added] = append(byLetter[added], w)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sa := &StemAnalysis{Stem: append(tilemapping.MachineWord(nil), stem...)}
+	for ml, words := range byLetter {
+		if len(words) > 0 {
+			sa.Entries = append(sa.Entries, StemEntry{
+				Letter: tilemapping.MachineLetter(ml),
+				Words:  words,
+			})
+		}
+	}
+	return sa, nil
+}
+
+// AnalyzeThrough is like AnalyzeStem, but only reports the given board
+// letters, e.g. the letters a 7-tile rack could bingo through. Every
+// requested letter gets an entry, in alphabet order, even if it makes no
+// words. Blanked board letters count as their natural letter.
+func (da *KWGAnagrammer) AnalyzeThrough(kwg *KWG, rack tilemapping.MachineWord,
+	through []tilemapping.MachineLetter) (*StemAnalysis, error) {
+
+	sa, err := da.AnalyzeStem(kwg, rack)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make([]bool, kwg.GetAlphabet().NumLetters())
+	for _, ml := range through {
+		ml = ml.Unblank()
+		if ml == 0 || int(ml) >= len(wanted) {
+			return nil, errors.New("invalid board letter")
+		}
+		wanted[ml] = true
+	}
+	entries := []StemEntry{}
+	ei := 0
+	for ml := range wanted {
+		for ei < len(sa.Entries) && int(sa.Entries[ei].Letter) < ml {
+			ei++
+		}
+		if !wanted[ml] {
+			continue
+		}
+		e := StemEntry{Letter: tilemapping.MachineLetter(ml), Words: []tilemapping.MachineWord{}}
+		if ei < len(sa.Entries) && int(sa.Entries[ei].Letter) == ml {
+			e.Words = sa.Entries[ei].Words
+		}
+		entries = append(entries, e)
+	}
+	sa.Entries = entries
+	return sa, nil
+}
@@ -0,0 +1,77 @@
+package kwg
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+func TestAnalyzeStem(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST",
+		"CARE", "RACE", "ACRE", "SCARE", "CARES", "RACES", "ACRES", "CRATE", "TRACE",
+		"CARET", "REACT", "RECTA", "CARED", "CEDAR", "RACED", "ARCED")
+	alph := k.GetAlphabet()
+
+	da := KWGAnagrammer{}
+	sa, err := da.AnalyzeStem(k, testMW(t, k, "ACER"))
+	is.NoErr(err)
+	is.Equal(sa.Stem.UserVisible(alph), "ACER")
+	is.Equal(tilemapping.MachineWord(sa.BingoLetters()).UserVisible(alph), "DST")
+	is.Equal(userVisibleWords(sa.Entries[0].Words, alph), []string{"ARCED", "CARED", "CEDAR", "RACED"})
+	is.Equal(userVisibleWords(sa.Entries[1].Words, alph), []string{"ACRES", "CARES", "RACES", "SCARE"})
+	is.Equal(userVisibleWords(sa.Entries[2].Words, alph), []string{"CARET", "CRATE", "REACT", "RECTA", "TRACE"})
+
+	ld := testEnglishLD(t)
+	// 4 D + 4 S + 6 T + 2 blanks
+	is.Equal(sa.Usefulness(ld.Distribution()), 16)
+}
+
+func TestAnalyzeStemRepeatedLetter(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "AA", "BAA", "ABA", "AB")
+	alph := k.GetAlphabet()
+
+	da := KWGAnagrammer{}
+	sa, err := da.AnalyzeStem(k, testMW(t, k, "A"))
+	is.NoErr(err)
+	is.Equal(len(sa.Entries), 2)
+	is.Equal(alph.Letter(sa.Entries[0].Letter), "A")
+	is.Equal(userVisibleWords(sa.Entries[0].Words, alph), []string{"AA"})
+	is.Equal(alph.Letter(sa.Entries[1].Letter), "B")
+	is.Equal(userVisibleWords(sa.Entries[1].Words, alph), []string{"AB"})
+
+	sa, err = da.AnalyzeStem(k, testMW(t, k, "AB"))
+	is.NoErr(err)
+	is.Equal(len(sa.Entries), 1)
+	is.Equal(userVisibleWords(sa.Entries[0].Words, alph), []string{"ABA", "BAA"})
+}
+
+func TestAnalyzeThrough(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "CARET", "CRATE", "CARED", "SCARE")
+	alph := k.GetAlphabet()
+
+	da := KWGAnagrammer{}
+	sa, err := da.AnalyzeThrough(k, testMW(t, k, "ACER"), testMW(t, k, "TzT"))
+	is.NoErr(err)
+	is.Equal(len(sa.Entries), 2)
+	is.Equal(alph.Letter(sa.Entries[0].Letter), "T")
+	is.Equal(userVisibleWords(sa.Entries[0].Words, alph), []string{"CARET", "CRATE"})
+	is.Equal(alph.Letter(sa.Entries[1].Letter), "Z")
+	is.Equal(len(sa.Entries[1].Words), 0)
+	is.Equal(sa.Usefulness(testEnglishLD(t).Distribution()), 8)
+}
+
+func TestAnalyzeStemErrors(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "CARE")
+
+	da := KWGAnagrammer{}
+	_, err := da.AnalyzeStem(k, nil)
+	is.Equal(err, errEmptyStem)
+	_, err = da.AnalyzeStem(k, testMW(t, k, "AC?"))
+	is.Equal(err, errHasBlanks)
+}
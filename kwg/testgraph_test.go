@@ -0,0 +1,214 @@
+package kwg
+
+import (
+	"bytes"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/domino14/word-golib/config"
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// The tests in this file's siblings that use buildTestKWG don't need a
+// DATA_PATH: they build a tiny, unminimized KWG (DAWG + GADDAG) in memory
+// from a handful of words over the standard English distribution. The
+// graph is built by a plain trie builder here rather than by buildKWGNodes,
+// so that the graph tests don't rely on the code under test.
+
+const testEnglishDist = `?,2,0,0
+A,9,1,1
+B,2,3,0
+C,2,3,0
+D,4,2,0
+E,12,1,1
+F,2,4,0
+G,3,2,0
+H,2,4,0
+I,9,1,1
+J,1,8,0
+K,1,5,0
+L,4,1,0
+M,2,3,0
+N,6,1,0
+O,8,1,1
+P,2,3,0
+Q,1,10,0
+R,6,1,0
+S,4,1,0
+T,6,1,0
+U,4,1,1
+V,2,4,0
+W,2,4,0
+X,1,8,0
+Y,2,4,0
+Z,1,10,0
+`
+
+func testEnglishLD(t testing.TB) *tilemapping.LetterDistribution {
+	t.Helper()
+	ld, err := tilemapping.ScanLetterDistribution(strings.NewReader(testEnglishDist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ld.Name = "english"
+	return ld
+}
+
+type testTrieNode struct {
+	children map[tilemapping.MachineLetter]*testTrieNode
+	accepts  bool
+}
+
+func (n *testTrieNode) insert(word tilemapping.MachineWord) {
+	for _, ml := range word {
+		if n.children == nil {
+			n.children = map[tilemapping.MachineLetter]*testTrieNode{}
+		}
+		c, ok := n.children[ml]
+		if !ok {
+			c = &testTrieNode{}
+			n.children[ml] = c
+		}
+		n = c
+	}
+	n.accepts = true
+}
+
+// place appends n's arc list to nodes and returns its index, or 0 if n
+// has no children.
+func (n *testTrieNode) place(nodes *[]uint32) uint32 {
+	if len(n.children) == 0 {
+		return 0
+	}
+	tiles := make([]tilemapping.MachineLetter, 0, len(n.children))
+	for ml := range n.children {
+		tiles = append(tiles, ml)
+	}
+	sort.Slice(tiles, func(i, j int) bool { return tiles[i] < tiles[j] })
+	start := uint32(len(*nodes))
+	*nodes = append(*nodes, make([]uint32, len(tiles))...)
+	for i, ml := range tiles {
+		c := n.children[ml]
+		v := uint32(ml)<<KWGNodeTileShift | c.place(nodes)
+		if c.accepts {
+			v |= KWGNodeAcceptsBit
+		}
+		if i == len(tiles)-1 {
+			v |= KWGNodeIsEndBit
+		}
+		(*nodes)[start+uint32(i)] = v
+	}
+	return start
+}
+
+// buildTestKWG builds a KWG containing the given words, named lexName.
+func buildTestKWG(t testing.TB, lexName string, words ...string) *KWG {
+	t.Helper()
+	return buildTestKWGWithDist(t, testEnglishLD(t), lexName, words...)
+}
+
+func buildTestKWGWithDist(t testing.TB, ld *tilemapping.LetterDistribution, lexName string, words ...string) *KWG {
+	t.Helper()
+	mws := make([]tilemapping.MachineWord, len(words))
+	for i, w := range words {
+		mw, err := tilemapping.ToMachineLetters(w, ld.TileMapping())
+		if err != nil {
+			t.Fatal(err)
+		}
+		mws[i] = mw
+	}
+	k := newKWG(buildTestNodes(t, mws, true))
+	k.setAlphabet(ld.TileMapping())
+	k.lexiconName = lexName
+	return k
+}
+
+// writeTestLexicon builds a KWG of the given words over the fakeDistOne
+// distribution, named name, and writes its nodes to the lexicon file of
+// that name with the given extension in cfg's data path, gzip-compressed
+// if ext ends in .gz. It also writes the distribution.
+func writeTestLexicon(t *testing.T, cfg *config.Config, name, ext string, words ...string) *KWG {
+	t.Helper()
+	writeFakeDist(t, cfg, fakeDistOne, fakeDistOneContent)
+	ld, err := tilemapping.NamedLetterDistribution(cfg, fakeDistOne)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := buildTestKWGWithDist(t, ld, name, words...)
+	path := lexiconFilePath(cfg, name, ext)
+	if strings.HasSuffix(ext, gzipExt) {
+		writeGzippedGraph(t, k, path)
+		return k
+	}
+	buf := &bytes.Buffer{}
+	if err := k.Save(buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// buildTestNodes returns the node array of a KWG with a DAWG of the given
+// words and, if withGaddag is set, a GADDAG of them.
+func buildTestNodes(t testing.TB, words []tilemapping.MachineWord, withGaddag bool) []uint32 {
+	t.Helper()
+	dawg := &testTrieNode{}
+	gaddag := &testTrieNode{}
+	for _, mw := range words {
+		dawg.insert(mw)
+		if !withGaddag {
+			continue
+		}
+		gaddag.insert(reverse(mw))
+		for i := len(mw) - 1; i > 0; i-- {
+			entry := append(reverse(mw[:i]), 0)
+			gaddag.insert(append(entry, mw[i:]...))
+		}
+	}
+	nodes := []uint32{KWGNodeIsEndBit, KWGNodeIsEndBit}
+	nodes[0] |= dawg.place(&nodes)
+	nodes[1] |= gaddag.place(&nodes)
+	return nodes
+}
+
+func testMW(t testing.TB, k *KWG, word string) tilemapping.MachineWord {
+	t.Helper()
+	mw, err := tilemapping.ToMachineWord(word, k.GetAlphabet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mw
+}
+
+func userVisibleWords(words []tilemapping.MachineWord, tm *tilemapping.TileMapping) []string {
+	strs := make([]string, len(words))
+	for i, w := range words {
+		strs[i] = w.UserVisible(tm)
+	}
+	return strs
+}
+
+func TestBuildTestKWG(t *testing.T) {
+	k := buildTestKWG(t, "TEST", "CARE", "RACE", "ACRE", "CARES", "SCARE")
+	l := Lexicon{KWG: *k}
+	for _, w := range []string{"CARE", "RACE", "ACRE", "CARES", "SCARE"} {
+		if !l.HasWord(testMW(t, k, w)) {
+			t.Errorf("expected %v to be a word", w)
+		}
+	}
+	if l.HasWord(testMW(t, k, "CAR")) {
+		t.Error("CAR should not be a word")
+	}
+	hooks := FindHooks(k, testMW(t, k, "CARE"), FrontHooks)
+	if tilemapping.MachineWord(hooks).UserVisible(k.GetAlphabet()) != "S" {
+		t.Errorf("unexpected front hooks %v", hooks)
+	}
+	hooks = FindHooks(k, testMW(t, k, "CARE"), BackHooks)
+	if tilemapping.MachineWord(hooks).UserVisible(k.GetAlphabet()) != "S" {
+		t.Errorf("unexpected back hooks %v", hooks)
+	}
+}
@@ -0,0 +1,404 @@
+package kwg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/domino14/word-golib/cache"
+	"github.com/domino14/word-golib/config"
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// A WMP is a word map, as used by the MAGPIE and wolges move generators
+// next to the KWG: a set of hash tables, one per word length, mapping a
+// multiset of tiles straight to the words it makes. It answers "which words
+// use exactly these tiles" with up to two blanks in near-constant time.
+//
+// File layout (all integers little-endian):
+//
+//	u8  version
+//	u8  board dimension (the longest word length covered)
+//	u32 max word lookup bytes
+//	for each word length 2..board dimension:
+//	    a word table, a blank table and a double-blank table, each
+//	        u32 num buckets, u32 bucket starts[num buckets + 1],
+//	        u32 num entries, entry entries[num entries]
+//	    the word table is followed by
+//	        u32 num uninlined words, u8 letters[num uninlined words * length]
+//
+// Each entry is 32 bytes: a 16-byte value followed by the 16-byte quotient
+// of the entry's key divided by the number of buckets in its table (the
+// remainder is the bucket index). Keys are bit racks: 4 bits of count per
+// MachineLetter, blank at 0, in 128 bits (low word first).
+//
+// A word-table value is either inline (first byte nonzero), holding up to
+// 16/length words back to back, or a u32 start (in words) and u32 count
+// into the uninlined letters at bytes 8..16. A blank-table value is a u64
+// letter set at bytes 8..16: the letters a blank in the key can stand for
+// and still make a word. A double-blank value is the same, for the first of
+// the two blanks.
+type WMP struct {
+	version            uint8
+	boardDim           uint8
+	maxWordLookupBytes uint32
+	// tables is indexed by word length.
+	tables      []wmpForLength
+	alphabet    *tilemapping.TileMapping
+	lexiconName string
+}
+
+// WMPVersion is the only WMP version this package reads.
+const WMPVersion = 1
+
+const (
+	wmpEntrySize       = 32
+	wmpInlineValueSize = 16
+	wmpMaxAlphabetSize = 32
+)
+
+type wmpTable struct {
+	bucketStarts []uint32
+	entries      []byte
+}
+
+type wmpForLength struct {
+	words        wmpTable
+	wordLetters  []byte
+	blanks       wmpTable
+	doubleBlanks wmpTable
+}
+
+// bitRack is a multiset of tiles with a 4-bit count per MachineLetter.
+type bitRack struct {
+	lo, hi uint64
+}
+
+func (br *bitRack) add(ml tilemapping.MachineLetter) {
+	shift := uint(ml) * 4
+	if shift < 64 {
+		br.lo += 1 << shift
+	} else {
+		br.hi += 1 << (shift - 64)
+	}
+}
+
+func (br *bitRack) remove(ml tilemapping.MachineLetter) {
+	shift := uint(ml) * 4
+	if shift < 64 {
+		br.lo -= 1 << shift
+	} else {
+		br.hi -= 1 << (shift - 64)
+	}
+}
+
+// divMod divides the bit rack, as a 128-bit integer, by d.
+func (br bitRack) divMod(d uint32) (bitRack, uint32) {
+	var q bitRack
+	var r uint64
+	q.hi, r = bits.Div64(0, br.hi, uint64(d))
+	q.lo, r = bits.Div64(r, br.lo, uint64(d))
+	return q, uint32(r)
+}
+
+type wmpReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *wmpReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.data) {
+		r.err = errors.New("wmp file is truncated")
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *wmpReader) u8() uint8 {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *wmpReader) u32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *wmpReader) table() wmpTable {
+	var t wmpTable
+	numBuckets := r.u32()
+	if r.err == nil && uint64(numBuckets+1)*4 > uint64(len(r.data)-r.pos) {
+		r.err = errors.New("wmp file is truncated")
+		return t
+	}
+	t.bucketStarts = make([]uint32, numBuckets+1)
+	for i := range t.bucketStarts {
+		t.bucketStarts[i] = r.u32()
+	}
+	numEntries := r.u32()
+	t.entries = r.next(int(numEntries) * wmpEntrySize)
+	if r.err != nil {
+		return t
+	}
+	for i := range numBuckets {
+		if t.bucketStarts[i] > t.bucketStarts[i+1] {
+			r.err = errors.New("wmp bucket starts are not sorted")
+			return t
+		}
+	}
+	if t.bucketStarts[numBuckets] != numEntries {
+		r.err = errors.New("wmp bucket starts do not match entry count")
+	}
+	return t
+}
+
+// ScanWMP reads a WMP from a reader.
+func ScanWMP(data io.Reader, filesize int) (*WMP, error) {
+	buf := make([]byte, filesize)
+	if _, err := io.ReadFull(data, buf); err != nil {
+		return nil, err
+	}
+	r := &wmpReader{data: buf}
+	w := &WMP{
+		version:            r.u8(),
+		boardDim:           r.u8(),
+		maxWordLookupBytes: r.u32(),
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if w.version != WMPVersion {
+		return nil, fmt.Errorf("unsupported wmp version %v", w.version)
+	}
+	w.tables = make([]wmpForLength, int(w.boardDim)+1)
+	for length := 2; length <= int(w.boardDim); length++ {
+		t := &w.tables[length]
+		t.words = r.table()
+		numUninlined := r.u32()
+		t.wordLetters = r.next(int(numUninlined) * length)
+		t.blanks = r.table()
+		t.doubleBlanks = r.table()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if err := t.checkWordEntries(length); err != nil {
+			return nil, err
+		}
+	}
+	log.Debug().Int("board-dim", int(w.boardDim)).Msg("loaded-wmp")
+	return w, nil
+}
+
+func (w *WMP) GetAlphabet() *tilemapping.TileMapping {
+	return w.alphabet
+}
+
+func (w *WMP) LexiconName() string {
+	return w.lexiconName
+}
+
+// MaxWordLookupBytes is the size of a buffer big enough to hold the result
+// of any single lookup, as recorded by the file's builder.
+func (w *WMP) MaxWordLookupBytes() int {
+	return int(w.maxWordLookupBytes)
+}
+
+// lookup returns the value of the entry for key in table t, or nil.
+func (t *wmpTable) lookup(key bitRack) []byte {
+	numBuckets := uint32(len(t.bucketStarts) - 1)
+	if numBuckets == 0 {
+		return nil
+	}
+	quotient, bucket := key.divMod(numBuckets)
+	for i := t.bucketStarts[bucket]; i < t.bucketStarts[bucket+1]; i++ {
+		entry := t.entries[i*wmpEntrySize : (i+1)*wmpEntrySize]
+		q := entry[wmpInlineValueSize:]
+		if binary.LittleEndian.Uint64(q) == quotient.lo && binary.LittleEndian.Uint64(q[8:]) == quotient.hi {
+			return entry[:wmpInlineValueSize]
+		}
+	}
+	return nil
+}
+
+// checkWordEntries makes sure that every uninlined word-table value points
+// inside the uninlined letters, so that a corrupt file fails to load rather
+// than panicking on lookup.
+func (tl *wmpForLength) checkWordEntries(length int) error {
+	numUninlined := uint64(len(tl.wordLetters) / length)
+	for i := 0; i < len(tl.words.entries); i += wmpEntrySize {
+		value := tl.words.entries[i : i+wmpInlineValueSize]
+		if value[0] != 0 {
+			continue
+		}
+		start := binary.LittleEndian.Uint32(value[8:])
+		count := binary.LittleEndian.Uint32(value[12:])
+		if uint64(start)+uint64(count) > numUninlined {
+			return fmt.Errorf("wmp word entry for length %d points past the uninlined words", length)
+		}
+	}
+	return nil
+}
+
+func (tl *wmpForLength) appendWords(words []tilemapping.MachineWord, key bitRack, length int) []tilemapping.MachineWord {
+	value := tl.words.lookup(key)
+	if value == nil {
+		return words
+	}
+	if value[0] != 0 {
+		for i := 0; i+length <= len(value) && value[i] != 0; i += length {
+			words = append(words, tilemapping.FromByteArr(value[i:i+length]))
+		}
+		return words
+	}
+	start := binary.LittleEndian.Uint32(value[8:])
+	count := binary.LittleEndian.Uint32(value[12:])
+	for i := start; i < start+count; i++ {
+		words = append(words, tilemapping.FromByteArr(tl.wordLetters[int(i)*length:int(i+1)*length]))
+	}
+	return words
+}
+
+func blankLetters(value []byte) tilemapping.LetterSet {
+	if value == nil {
+		return 0
+	}
+	return tilemapping.LetterSet(binary.LittleEndian.Uint64(value[8:]))
+}
+
+// Anagrams returns every word made of exactly the given tiles, in the same
+// (alphabetical) order as KWGAnagrammer.Anagram. The rack may contain up to
+// two blanks; in the returned words they appear as the letters they stand
+// for.
+func (w *WMP) Anagrams(rack tilemapping.MachineWord) ([]tilemapping.MachineWord, error) {
+	words := []tilemapping.MachineWord{}
+	length := len(rack)
+	if length < 2 || length > int(w.boardDim) {
+		return words, nil
+	}
+	var key bitRack
+	blanks := 0
+	for _, ml := range rack {
+		if ml.IsBlanked() || ml >= wmpMaxAlphabetSize {
+			return nil, fmt.Errorf("invalid tile %v", ml)
+		}
+		if ml == 0 {
+			blanks++
+		}
+		key.add(ml)
+	}
+	tl := &w.tables[length]
+	switch blanks {
+	case 0:
+		words = tl.appendWords(words, key, length)
+	case 1:
+		words = tl.appendBlankWords(words, key, length, 1)
+	case 2:
+		firsts := blankLetters(tl.doubleBlanks.lookup(key))
+		key.remove(0)
+		for x := tilemapping.MachineLetter(1); x < wmpMaxAlphabetSize; x++ {
+			if firsts&(1<<x) == 0 {
+				continue
+			}
+			key.add(x)
+			// Only take second letters from x on, so that each pair of
+			// letters is only tried once.
+			words = tl.appendBlankWords(words, key, length, x)
+			key.remove(x)
+		}
+	default:
+		return nil, errors.New("wmp lookups support at most two blanks")
+	}
+	sort.Slice(words, func(i, j int) bool {
+		return compareMachineWords(words[i], words[j]) < 0
+	})
+	return words, nil
+}
+
+// appendBlankWords looks up a key with exactly one blank, trying only
+// letters from minLetter on for it.
+func (tl *wmpForLength) appendBlankWords(words []tilemapping.MachineWord, key bitRack, length int,
+	minLetter tilemapping.MachineLetter) []tilemapping.MachineWord {
+
+	letters := blankLetters(tl.blanks.lookup(key))
+	key.remove(0)
+	for y := minLetter; y < wmpMaxAlphabetSize; y++ {
+		if letters&(1<<y) == 0 {
+			continue
+		}
+		key.add(y)
+		words = tl.appendWords(words, key, length)
+		key.remove(y)
+	}
+	return words
+}
+
+// LoadWMP loads a WMP from a file. By default, the letter distribution (and
+// thus alphabet) is guessed from the lexicon name; pass WithDistribution to
+// override that guess explicitly.
+func LoadWMP(cfg *config.Config, filename string, opts ...LoadOption) (*WMP, error) {
+	o := resolveLoadOpts(opts)
+	return loadWMP(cfg, filename, o.distName)
+}
+
+func loadWMP(cfg *config.Config, filename, distName string) (*WMP, error) {
+	log.Debug().Msgf("Loading %v ...", filename)
+	file, filesize, err := cache.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	w, err := ScanWMP(file, filesize)
+	if err != nil {
+		return nil, err
+	}
+	w.lexiconName, w.alphabet, err = lexiconMetadata(cfg, filename, distName)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// CacheLoadFuncWMP is the function that loads a WMP into the global cache
+func CacheLoadFuncWMP(cfg *config.Config, key string) (interface{}, error) {
+	lexiconName := strings.TrimPrefix(key, CacheKeyPrefixWMP)
+	return loadWMP(cfg, lexiconFilePath(cfg, lexiconName, ".wmp"), "")
+}
+
+// GetWMP loads a named WMP from the cache or from a file. It lives next to
+// the lexicon's KWG. See GetKWG for the options.
+func GetWMP(cfg *config.Config, name string, opts ...LoadOption) (*WMP, error) {
+	o := resolveLoadOpts(opts)
+	name, err := ResolveLexiconName(cfg, name, ".wmp")
+	if err != nil {
+		return nil, err
+	}
+	key := o.cacheKey(CacheKeyPrefixWMP, name)
+	obj, err := cache.Load(cfg, key, func(cfg *config.Config, _ string) (interface{}, error) {
+		return loadWMP(cfg, lexiconFilePath(cfg, name, ".wmp"), o.distName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	wmp, ok := obj.(*WMP)
+	if !ok {
+		return nil, errors.New("could not convert cached object to WMP")
+	}
+	return wmp, nil
+}
@@ -0,0 +1,311 @@
+package kwg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+type testWMPEntry struct {
+	key   bitRack
+	value [wmpInlineValueSize]byte
+}
+
+func putTestWMPTable(buf *bytes.Buffer, entries []testWMPEntry) {
+	numBuckets := uint32(len(entries))
+	if numBuckets == 0 {
+		numBuckets = 1
+	}
+	buckets := make([][]testWMPEntry, numBuckets)
+	for _, e := range entries {
+		_, b := e.key.divMod(numBuckets)
+		buckets[b] = append(buckets[b], e)
+	}
+	binary.Write(buf, binary.LittleEndian, numBuckets)
+	start := uint32(0)
+	for _, b := range buckets {
+		binary.Write(buf, binary.LittleEndian, start)
+		start += uint32(len(b))
+	}
+	binary.Write(buf, binary.LittleEndian, start)
+	binary.Write(buf, binary.LittleEndian, uint32(len(entries)))
+	for _, b := range buckets {
+		for _, e := range b {
+			q, _ := e.key.divMod(numBuckets)
+			buf.Write(e.value[:])
+			binary.Write(buf, binary.LittleEndian, q.lo)
+			binary.Write(buf, binary.LittleEndian, q.hi)
+		}
+	}
+}
+
+func testBitRackCount(br bitRack, ml tilemapping.MachineLetter) int {
+	shift := uint(ml) * 4
+	if shift < 64 {
+		return int(br.lo>>shift) & 0xf
+	}
+	return int(br.hi>>(shift-64)) & 0xf
+}
+
+// blankTestWMPEntries returns, for every key with one more blank than the
+// given keys, the letters that blank can stand for.
+func blankTestWMPEntries(keys map[bitRack]bool, letters []tilemapping.MachineLetter) map[bitRack]uint64 {
+	masks := map[bitRack]uint64{}
+	for k := range keys {
+		for _, x := range letters {
+			if testBitRackCount(k, x) == 0 {
+				continue
+			}
+			bk := k
+			bk.remove(x)
+			bk.add(0)
+			masks[bk] |= 1 << x
+		}
+	}
+	return masks
+}
+
+// buildTestWMP writes a WMP for every word in k, the way a WMP builder
+// would, and reads it back.
+func buildTestWMP(t *testing.T, k *KWG, boardDim int) *WMP {
+	t.Helper()
+	data := writeTestWMP(t, k, boardDim)
+	w, err := ScanWMP(bytes.NewReader(data), len(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.alphabet = k.GetAlphabet()
+	w.lexiconName = k.LexiconName()
+	return w
+}
+
+// writeTestWMP returns the WMP file of every word in k.
+func writeTestWMP(t *testing.T, k *KWG, boardDim int) []byte {
+	t.Helper()
+	byLength := make([]map[bitRack][]tilemapping.MachineWord, boardDim+1)
+	for i := range byLength {
+		byLength[i] = map[bitRack][]tilemapping.MachineWord{}
+	}
+	err := Lexicon{KWG: *k}.ForEachWord(func(w tilemapping.MachineWord) error {
+		var key bitRack
+		for _, ml := range w {
+			key.add(ml)
+		}
+		byLength[len(w)][key] = append(byLength[len(w)][key], append(tilemapping.MachineWord(nil), w...))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	letters := []tilemapping.MachineLetter{}
+	for ml := 1; ml < int(k.GetAlphabet().NumLetters()); ml++ {
+		letters = append(letters, tilemapping.MachineLetter(ml))
+	}
+
+	buf := &bytes.Buffer{}
+	buf.Write([]byte{WMPVersion, byte(boardDim)})
+	binary.Write(buf, binary.LittleEndian, uint32(1024))
+	for length := 2; length <= boardDim; length++ {
+		entries := []testWMPEntry{}
+		uninlined := []byte{}
+		keys := map[bitRack]bool{}
+		for key, words := range byLength[length] {
+			keys[key] = true
+			e := testWMPEntry{key: key}
+			if len(words)*length <= wmpInlineValueSize {
+				for i, w := range words {
+					copy(e.value[i*length:], w.ToByteArr())
+				}
+			} else {
+				binary.LittleEndian.PutUint32(e.value[8:], uint32(len(uninlined)/length))
+				binary.LittleEndian.PutUint32(e.value[12:], uint32(len(words)))
+				for _, w := range words {
+					uninlined = append(uninlined, w.ToByteArr()...)
+				}
+			}
+			entries = append(entries, e)
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].key.lo < entries[j].key.lo })
+		putTestWMPTable(buf, entries)
+		binary.Write(buf, binary.LittleEndian, uint32(len(uninlined)/length))
+		buf.Write(uninlined)
+
+		// One-blank table, then double-blank table.
+		for range 2 {
+			masks := blankTestWMPEntries(keys, letters)
+			entries = []testWMPEntry{}
+			keys = map[bitRack]bool{}
+			for key, mask := range masks {
+				e := testWMPEntry{key: key}
+				binary.LittleEndian.PutUint64(e.value[8:], mask)
+				entries = append(entries, e)
+				keys[key] = true
+			}
+			putTestWMPTable(buf, entries)
+		}
+	}
+	return buf.Bytes()
+}
+
+var wmpTestWords = []string{
+	"AA", "AB", "BA", "QI", "ZA", "ABA", "BAA", "AAH", "HAH",
+	"CARE", "RACE", "ACRE", "ACER", "CARES", "RACES", "SCARE", "ACRES", "SCRAE",
+	"CRATE", "TRACE", "CARET", "REACT", "RECTA", "CATER", "CARTE", "TERAS",
+	"ASTER", "RATES", "STARE", "TARES", "TEARS", "RESAT", "TASER",
+}
+
+func TestWMPMatchesAnagrammer(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", wmpTestWords...)
+	w := buildTestWMP(t, k, 7)
+	alph := k.GetAlphabet()
+
+	racks := []string{
+		"AB", "AA", "A?", "??", "IQ", "AAB", "A?A", "?AH", "H??",
+		"ACER", "ACE?", "AC??", "AERST", "AERS?", "AER??", "ACERT", "?CERT",
+		"ACERS", "ZZ", "ZZZZ", "ABCDEFG",
+	}
+	da := KWGAnagrammer{}
+	for _, rack := range racks {
+		mw := testMW(t, k, rack)
+		want := []string{}
+		is.NoErr(da.InitForMachineWord(k, mw))
+		is.NoErr(da.Anagram(k, func(word tilemapping.MachineWord) error {
+			want = append(want, word.UserVisible(alph))
+			return nil
+		}))
+		got, err := w.Anagrams(mw)
+		is.NoErr(err)
+		if len(want) == 0 {
+			is.Equal(len(got), 0)
+			continue
+		}
+		is.Equal(userVisibleWords(got, alph), want)
+	}
+}
+
+func TestWMPErrors(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", wmpTestWords...)
+	w := buildTestWMP(t, k, 7)
+
+	_, err := w.Anagrams(testMW(t, k, "A???"))
+	is.True(err != nil)
+	_, err = w.Anagrams(testMW(t, k, "Ab"))
+	is.True(err != nil)
+	// Longer than the board: nothing, but no error.
+	got, err := w.Anagrams(testMW(t, k, "AAAAAAAA"))
+	is.NoErr(err)
+	is.Equal(len(got), 0)
+
+	_, err = ScanWMP(bytes.NewReader([]byte{WMPVersion, 7, 0, 0, 0, 0, 1}), 7)
+	is.True(err != nil)
+	_, err = ScanWMP(bytes.NewReader([]byte{9, 0, 0, 0, 0, 0}), 6)
+	is.True(err != nil)
+}
+
+func TestScanWMPRejectsCorruptFiles(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", wmpTestWords...)
+	data := writeTestWMP(t, k, 7)
+	for n := range len(data) {
+		_, err := ScanWMP(bytes.NewReader(data[:n]), n)
+		is.True(err != nil)
+	}
+
+	// An uninlined word entry pointing past the (empty) uninlined words.
+	var entry testWMPEntry
+	binary.LittleEndian.PutUint32(entry.value[8:], 0)
+	binary.LittleEndian.PutUint32(entry.value[12:], 1)
+	buf := &bytes.Buffer{}
+	buf.Write([]byte{WMPVersion, 2, 0, 0, 0, 0})
+	putTestWMPTable(buf, []testWMPEntry{entry})
+	binary.Write(buf, binary.LittleEndian, uint32(0))
+	putTestWMPTable(buf, nil)
+	putTestWMPTable(buf, nil)
+	_, err := ScanWMP(bytes.NewReader(buf.Bytes()), buf.Len())
+	is.True(err != nil)
+}
+
+// TestWMPMagpieFixture checks WMP files made by MAGPIE's own builder
+// against the anagrammer. Each word list testdata/magpie/<name>.txt (one
+// word per line, English distribution) goes with the WMP built from it,
+// <name>.wmp; see testdata/magpie/README.md. Word lists without a WMP are
+// skipped.
+func TestWMPMagpieFixture(t *testing.T) {
+	is := is.New(t)
+	lists, err := filepath.Glob(filepath.Join("testdata", "magpie", "*.txt"))
+	is.NoErr(err)
+	for _, listFile := range lists {
+		fixture := strings.TrimSuffix(listFile, ".txt") + ".wmp"
+		if _, err := os.Stat(fixture); errors.Is(err, os.ErrNotExist) {
+			t.Logf("skipping %s: no MAGPIE-built %s", listFile, fixture)
+			continue
+		}
+		list, err := os.ReadFile(listFile)
+		is.NoErr(err)
+		words := strings.Fields(string(list))
+		k := buildTestKWG(t, "TEST", words...)
+		data, err := os.ReadFile(fixture)
+		is.NoErr(err)
+		w, err := ScanWMP(bytes.NewReader(data), len(data))
+		is.NoErr(err)
+
+		da := KWGAnagrammer{}
+		for _, word := range words {
+			mw := testMW(t, k, word)
+			// The word itself, and with one and two of its letters blanked.
+			racks := []tilemapping.MachineWord{mw}
+			for i := 0; i < len(mw) && len(racks) < 3; i++ {
+				r := append(tilemapping.MachineWord{}, racks[len(racks)-1]...)
+				r[i] = 0
+				racks = append(racks, r)
+			}
+			for _, rack := range racks {
+				want := []string{}
+				is.NoErr(da.InitForMachineWord(k, rack))
+				is.NoErr(da.Anagram(k, func(word tilemapping.MachineWord) error {
+					want = append(want, word.UserVisible(k.GetAlphabet()))
+					return nil
+				}))
+				got, err := w.Anagrams(rack)
+				is.NoErr(err)
+				is.Equal(append([]string{}, userVisibleWords(got, k.GetAlphabet())...), want)
+			}
+		}
+	}
+}
+
+func TestGetWMP(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	writeFakeDist(t, cfg, fakeDistOne, fakeDistOneContent)
+	k := buildTestKWG(t, "TEST", "AB", "BA", "AAB")
+	path := filepath.Join(cfg.DataPath, "lexica", "gaddag", "ZZZWMP01.wmp")
+	is.NoErr(os.WriteFile(path, []byte{WMPVersion, 2, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // words: 0 buckets, 0 entries, 0 uninlined
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // blanks
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // double blanks
+	}, 0644))
+
+	w, err := GetWMP(cfg, "ZZZWMP01", WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	is.Equal(w.LexiconName(), "ZZZWMP01")
+	is.Equal(w.GetAlphabet().NumLetters(), uint8(3))
+	got, err := w.Anagrams(testMW(t, k, "AB"))
+	is.NoErr(err)
+	is.Equal(len(got), 0)
+
+	again, err := GetWMP(cfg, "ZZZWMP01", WithDistribution(fakeDistOne))
+	is.NoErr(err)
+	is.True(w == again)
+}
@@ -0,0 +1,114 @@
+package kwg
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/domino14/word-golib/cache"
+)
+
+// WordCountSidecarExt is appended to a graph's file name to get the name of
+// its word-count sidecar file, e.g. CSW21.kwg.wc.
+const WordCountSidecarExt = ".wc"
+
+// WordCountIndex is a read-only view of a KWG's word-count index: for every
+// node, the number of words in the subtree rooted at it.
+type WordCountIndex struct {
+	counts []int32
+}
+
+// At returns the number of words in the subtree rooted at nodeIdx.
+func (w WordCountIndex) At(nodeIdx uint32) int32 {
+	return w.counts[nodeIdx]
+}
+
+// Len returns the number of nodes covered by the index.
+func (w WordCountIndex) Len() int {
+	return len(w.counts)
+}
+
+// nodesHash identifies a node array, so that a word-count sidecar can't be
+// applied to a different graph.
+func (k *KWG) nodesHash() uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 4*1024)
+	for i := 0; i < len(k.nodes); i += 1024 {
+		end := min(i+1024, len(k.nodes))
+		for j, n := range k.nodes[i:end] {
+			binary.LittleEndian.PutUint32(buf[4*j:], n)
+		}
+		h.Write(buf[:4*(end-i)])
+	}
+	return h.Sum64()
+}
+
+// SaveWordCounts writes the word-count index, building it if needed, in
+// the sidecar format read by LoadWordCounts:
+//
+//	u32 number of nodes, u64 FNV-1a hash of the node array (little-endian
+//	u32s), then one little-endian int32 count per node
+func (k *KWG) SaveWordCounts(w io.Writer) error {
+	counts := k.wordCountSlice()
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(counts))); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, k.nodesHash()); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, counts)
+}
+
+// LoadWordCounts reads a word-count index written by SaveWordCounts, so that
+// it doesn't have to be counted. It fails if the index was saved for a
+// different graph, i.e. if its node count or node hash differ, or if a
+// count is negative; the counts aren't otherwise checked. If the index has
+// already been built, the saved one is read and checked but not used.
+func (k *KWG) LoadWordCounts(r io.Reader) error {
+	var numNodes uint32
+	var hash uint64
+	if err := binary.Read(r, binary.LittleEndian, &numNodes); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &hash); err != nil {
+		return err
+	}
+	if int(numNodes) != len(k.nodes) || hash != k.nodesHash() {
+		return errors.New("word counts were saved for a different graph")
+	}
+	counts := make([]int32, numNodes)
+	if err := binary.Read(r, binary.LittleEndian, counts); err != nil {
+		return err
+	}
+	for _, c := range counts {
+		if c < 0 {
+			return errors.New("word counts must not be negative")
+		}
+	}
+	if k.wordCounts == nil {
+		return errors.New("graph has no room for a word-count index")
+	}
+	k.wordCounts.once.Do(func() {
+		k.wordCounts.counts = counts
+	})
+	return nil
+}
+
+// loadWordCountSidecar builds k's word-count index from the sidecar file of
+// the graph file filename, or by counting if there is no usable sidecar.
+func loadWordCountSidecar(k *KWG, filename string) {
+	sidecar := filename + WordCountSidecarExt
+	file, _, err := cache.Open(sidecar)
+	if err == nil {
+		err = k.LoadWordCounts(file)
+		file.Close()
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn().Err(err).Str("sidecar", sidecar).Msg("ignoring-word-count-sidecar")
+	}
+	k.CountWords()
+}
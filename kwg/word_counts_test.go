@@ -0,0 +1,77 @@
+package kwg
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+var wordCountTestWords = []string{"AA", "AB", "ABA", "BA", "BAA", "CAB", "CABS", "QI", "SCAB", "ZA"}
+
+func TestWordCountsLazyAndConcurrent(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", wordCountTestWords...)
+
+	// No CountWords call: the index is built on first use, exactly once,
+	// no matter how many goroutines ask for it.
+	var wg sync.WaitGroup
+	indexes := make([]int32, len(wordCountTestWords))
+	for i, w := range wordCountTestWords {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			indexes[i] = k.GetWordIndexOf(k.ArcIndex(0), testMW(t, k, w))
+		}()
+	}
+	wg.Wait()
+	for i := range wordCountTestWords {
+		is.Equal(indexes[i], int32(i))
+	}
+	is.Equal(k.WordCountAt(k.ArcIndex(0)), int32(len(wordCountTestWords)))
+	is.Equal(k.WordCounts().Len(), len(k.Nodes()))
+	is.Equal(k.WordCounts().At(k.ArcIndex(0)), int32(len(wordCountTestWords)))
+
+	// Copies share the index.
+	l := Lexicon{KWG: *k}
+	is.Equal(&l.WordCounts().counts[0], &k.WordCounts().counts[0])
+	k.CountWords()
+	is.Equal(k.GetWordIndexOf(k.ArcIndex(0), testMW(t, k, "ZZ")), int32(-1))
+}
+
+func TestWordCountsSaveLoad(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", wordCountTestWords...)
+	buf := &bytes.Buffer{}
+	is.NoErr(k.SaveWordCounts(buf))
+
+	fresh := newKWG(append([]uint32(nil), k.Nodes()...))
+	is.NoErr(fresh.LoadWordCounts(bytes.NewReader(buf.Bytes())))
+	is.Equal(fresh.WordCounts().counts, k.WordCounts().counts)
+
+	other := buildTestKWG(t, "TEST", "QI", "ZA")
+	is.True(other.LoadWordCounts(bytes.NewReader(buf.Bytes())) != nil)
+	is.True(fresh.LoadWordCounts(bytes.NewReader(buf.Bytes()[:10])) != nil)
+}
+
+func TestLoadWordGraphWithWordCountSidecar(t *testing.T) {
+	is := is.New(t)
+	cfg := newTestConfig(t)
+	k := writeTestLexicon(t, cfg, "ZZZLEX20", ".kwg", "AB", "BA", "ABBA")
+	path := lexiconFilePath(cfg, "ZZZLEX20", ".kwg")
+	sidecar := &bytes.Buffer{}
+	is.NoErr(k.SaveWordCounts(sidecar))
+	is.NoErr(os.WriteFile(path+WordCountSidecarExt, sidecar.Bytes(), 0644))
+
+	loaded, err := LoadKWG(cfg, path, WithDistribution(fakeDistOne), WithWordCountSidecar())
+	is.NoErr(err)
+	is.Equal(loaded.WordCounts().counts, k.WordCounts().counts)
+
+	// A stale sidecar is ignored, and the counts are rebuilt.
+	is.NoErr(os.WriteFile(path+WordCountSidecarExt, sidecar.Bytes()[:12], 0644))
+	loaded, err = LoadKWG(cfg, path, WithDistribution(fakeDistOne), WithWordCountSidecar())
+	is.NoErr(err)
+	is.Equal(loaded.WordCounts().counts, k.WordCounts().counts)
+}
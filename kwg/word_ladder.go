@@ -0,0 +1,115 @@
+package kwg
+
+import (
+	"errors"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// Neighbors returns every word in the lexicon that differs from word in
+// exactly one tile, in DAWG (alphabetical) order. The word itself does not
+// need to be valid. Blank designations in word are ignored.
+func Neighbors[T WordGraphConstraint](d T, word tilemapping.MachineWord) []tilemapping.MachineWord {
+	neighbors := []tilemapping.MachineWord{}
+	if len(word) == 0 {
+		return neighbors
+	}
+	buf := make(tilemapping.MachineWord, len(word))
+	neighborsAt(d, d.ArcIndex(0), word, buf, 0, false, func(w tilemapping.MachineWord) {
+		neighbors = append(neighbors, append(tilemapping.MachineWord(nil), w...))
+	})
+	return neighbors
+}
+
+// neighborsAt walks the DAWG from nodeIdx, allowing at most one tile of word
+// to be substituted, and calls f for every word with exactly one substitution.
+func neighborsAt[T WordGraphConstraint](d T, nodeIdx uint32, word, buf tilemapping.MachineWord,
+	pos int, mismatched bool, f func(tilemapping.MachineWord)) {
+
+	if nodeIdx == 0 {
+		return
+	}
+	want := uint8(word[pos].Unblank())
+	for i := nodeIdx; ; i++ {
+		t := d.Tile(i)
+		same := t == want
+		if same || !mismatched {
+			buf[pos] = tilemapping.MachineLetter(t)
+			if pos == len(word)-1 {
+				if (mismatched || !same) && d.Accepts(i) {
+					f(buf)
+				}
+			} else {
+				neighborsAt(d, d.ArcIndex(i), word, buf, pos+1, mismatched || !same, f)
+			}
+		}
+		if d.IsEnd(i) {
+			return
+		}
+	}
+}
+
+// WordLadder returns a shortest ladder from one word to another of the same
+// length, where each step substitutes a single tile and every rung is a
+// valid word. The returned ladder starts with from and ends with to. If no
+// ladder exists, WordLadder returns nil and no error.
+func WordLadder[T WordGraphConstraint](d T, from, to tilemapping.MachineWord) ([]tilemapping.MachineWord, error) {
+	if len(from) != len(to) {
+		return nil, errors.New("ladder words must have the same length")
+	}
+	from = unblankedCopy(from)
+	to = unblankedCopy(to)
+	if !FindMachineWord(d, from) {
+		return nil, errors.New("start word is not in the lexicon")
+	}
+	if !FindMachineWord(d, to) {
+		return nil, errors.New("end word is not in the lexicon")
+	}
+	target := string(to.ToByteArr())
+	start := string(from.ToByteArr())
+	if start == target {
+		return []tilemapping.MachineWord{from}, nil
+	}
+
+	// Plain breadth-first search; parent doubles as the visited set.
+	parent := map[string]string{start: ""}
+	frontier := []tilemapping.MachineWord{from}
+	for len(frontier) > 0 {
+		var next []tilemapping.MachineWord
+		for _, w := range frontier {
+			key := string(w.ToByteArr())
+			for _, n := range Neighbors(d, w) {
+				nkey := string(n.ToByteArr())
+				if _, seen := parent[nkey]; seen {
+					continue
+				}
+				parent[nkey] = key
+				if nkey == target {
+					return unwindLadder(parent, target), nil
+				}
+				next = append(next, n)
+			}
+		}
+		frontier = next
+	}
+	return nil, nil
+}
+
+func unwindLadder(parent map[string]string, end string) []tilemapping.MachineWord {
+	ladder := []tilemapping.MachineWord{}
+	for key := end; key != ""; key = parent[key] {
+		ladder = append(ladder, tilemapping.FromByteArr([]byte(key)))
+	}
+	for i, j := 0, len(ladder)-1; i < j; i, j = i+1, j-1 {
+		ladder[i], ladder[j] = ladder[j], ladder[i]
+	}
+	return ladder
+}
+
+func unblankedCopy(word tilemapping.MachineWord) tilemapping.MachineWord {
+	w := make(tilemapping.MachineWord, len(word))
+	for i, ml := range word {
+		w[i] = ml.Unblank()
+	}
+	return w
+}
@@ -0,0 +1,63 @@
+package kwg
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestNeighbors(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "CAT", "COT", "CUT", "CAB", "BAT", "CATS", "DOG", "AT")
+	alph := k.GetAlphabet()
+
+	is.Equal(userVisibleWords(Neighbors(k, testMW(t, k, "CAT")), alph),
+		[]string{"BAT", "CAB", "COT", "CUT"})
+	// The word itself doesn't have to be valid.
+	is.Equal(userVisibleWords(Neighbors(k, testMW(t, k, "CIT")), alph),
+		[]string{"CAT", "COT", "CUT"})
+	// Blank designations are ignored.
+	is.Equal(userVisibleWords(Neighbors(k, testMW(t, k, "cAT")), alph),
+		[]string{"BAT", "CAB", "COT", "CUT"})
+	is.Equal(len(Neighbors(k, testMW(t, k, "DOG"))), 0)
+}
+
+func TestWordLadder(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST",
+		"COLD", "CORD", "CARD", "WARD", "WARM", "WORM", "WORD", "CORM", "GOLD")
+	alph := k.GetAlphabet()
+
+	ladder, err := WordLadder(k, testMW(t, k, "COLD"), testMW(t, k, "WARM"))
+	is.NoErr(err)
+	is.Equal(len(ladder), 5)
+	is.Equal(ladder[0].UserVisible(alph), "COLD")
+	is.Equal(ladder[4].UserVisible(alph), "WARM")
+	for i := 1; i < len(ladder); i++ {
+		diffs := 0
+		for j := range ladder[i] {
+			if ladder[i][j] != ladder[i-1][j] {
+				diffs++
+			}
+		}
+		is.Equal(diffs, 1)
+	}
+
+	ladder, err = WordLadder(k, testMW(t, k, "GOLD"), testMW(t, k, "GOLD"))
+	is.NoErr(err)
+	is.Equal(userVisibleWords(ladder, alph), []string{"GOLD"})
+}
+
+func TestWordLadderNoPath(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "CAT", "COT", "DOG", "CATS")
+
+	ladder, err := WordLadder(k, testMW(t, k, "CAT"), testMW(t, k, "DOG"))
+	is.NoErr(err)
+	is.True(ladder == nil)
+
+	_, err = WordLadder(k, testMW(t, k, "CAT"), testMW(t, k, "CATS"))
+	is.True(err != nil)
+	_, err = WordLadder(k, testMW(t, k, "CAT"), testMW(t, k, "ZZZ"))
+	is.True(err != nil)
+}
@@ -0,0 +1,173 @@
+package kwg
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+// A WordValidator answers word-validity questions for a lexicon. Lexicon is
+// the KWG-backed implementation; WordSetLexicon is a simple hash-set one,
+// handy for cross-checking and for lexica that only exist as word lists.
+type WordValidator interface {
+	Name() string
+	GetAlphabet() *tilemapping.TileMapping
+	HasWord(word tilemapping.MachineWord) bool
+	// HasAnagram returns true if some arrangement of the given tiles is a
+	// word. Blanks are not allowed.
+	HasAnagram(word tilemapping.MachineWord) bool
+	// ForEachWord calls f for every word, in alphabetical (MachineLetter)
+	// order. f must not modify or retain the given slice. If f returns an
+	// error, iteration stops and that error is returned.
+	ForEachWord(f func(tilemapping.MachineWord) error) error
+}
+
+var (
+	_ WordValidator = Lexicon{}
+	_ WordValidator = (*WordSetLexicon)(nil)
+)
+
+// ForEachWord calls f for every word in the lexicon, in DAWG order.
+func (l Lexicon) ForEachWord(f func(tilemapping.MachineWord) error) error {
+	return forEachWordAt(&l.KWG, l.ArcIndex(0), make(tilemapping.MachineWord, 0, 16), f)
+}
+
+func forEachWordAt[T WordGraphConstraint](d T, nodeIdx uint32, word tilemapping.MachineWord,
+	f func(tilemapping.MachineWord) error) error {
+
+	if nodeIdx == 0 {
+		return nil
+	}
+	for i := nodeIdx; ; i++ {
+		word = append(word, tilemapping.MachineLetter(d.Tile(i)))
+		if d.Accepts(i) {
+			if err := f(word); err != nil {
+				return err
+			}
+		}
+		if err := forEachWordAt(d, d.ArcIndex(i), word, f); err != nil {
+			return err
+		}
+		word = word[:len(word)-1]
+		if d.IsEnd(i) {
+			return nil
+		}
+	}
+}
+
+// WordSetLexicon is a WordValidator backed by plain hash sets of words and
+// their alphagrams.
+type WordSetLexicon struct {
+	name      string
+	alphabet  *tilemapping.TileMapping
+	words     map[string]struct{}
+	alphas    map[string]struct{}
+	wordOrder []string
+}
+
+func machineWordKey(mw tilemapping.MachineWord) string {
+	return string(mw.ToByteArr())
+}
+
+func alphagramKey(mw tilemapping.MachineWord) string {
+	sorted := append(tilemapping.MachineWord(nil), mw...)
+	tilemapping.SortMW(sorted)
+	return machineWordKey(sorted)
+}
+
+// NewWordSetLexicon creates a WordSetLexicon from the given words. Blank
+// designations are ignored and duplicates are dropped.
+func NewWordSetLexicon(name string, alph *tilemapping.TileMapping, words []tilemapping.MachineWord) *WordSetLexicon {
+	l := &WordSetLexicon{
+		name:     name,
+		alphabet: alph,
+		words:    make(map[string]struct{}, len(words)),
+		alphas:   make(map[string]struct{}, len(words)),
+	}
+	for _, w := range words {
+		w = unblankedCopy(w)
+		key := machineWordKey(w)
+		if _, ok := l.words[key]; ok {
+			continue
+		}
+		l.words[key] = struct{}{}
+		l.alphas[alphagramKey(w)] = struct{}{}
+		l.wordOrder = append(l.wordOrder, key)
+	}
+	sort.Strings(l.wordOrder)
+	return l
+}
+
+// ReadWordSetLexicon reads a word list with one word per line. Anything
+// after the first whitespace on a line (e.g. a definition) is ignored, as
+// are empty lines.
+func ReadWordSetLexicon(name string, alph *tilemapping.TileMapping, r io.Reader) (*WordSetLexicon, error) {
+	words := []tilemapping.MachineWord{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		mw, err := tilemapping.ToMachineWord(strings.ToUpper(fields[0]), alph)
+		if err != nil {
+			return nil, err
+		}
+		for _, ml := range mw {
+			if ml == 0 {
+				return nil, errors.New("word list entries must not contain blanks: " + fields[0])
+			}
+		}
+		words = append(words, mw)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewWordSetLexicon(name, alph, words), nil
+}
+
+func (l *WordSetLexicon) Name() string {
+	return l.name
+}
+
+func (l *WordSetLexicon) GetAlphabet() *tilemapping.TileMapping {
+	return l.alphabet
+}
+
+// HasWord returns whether word is in the lexicon. Like a KWG Lexicon, it
+// never accepts words shorter than two letters.
+func (l *WordSetLexicon) HasWord(word tilemapping.MachineWord) bool {
+	if len(word) < 2 {
+		return false
+	}
+	_, ok := l.words[machineWordKey(word)]
+	return ok
+}
+
+func (l *WordSetLexicon) HasAnagram(word tilemapping.MachineWord) bool {
+	for _, ml := range word {
+		if ml == 0 {
+			return false
+		}
+	}
+	_, ok := l.alphas[alphagramKey(word)]
+	return ok
+}
+
+// NumWords returns the number of words in the lexicon.
+func (l *WordSetLexicon) NumWords() int {
+	return len(l.wordOrder)
+}
+
+func (l *WordSetLexicon) ForEachWord(f func(tilemapping.MachineWord) error) error {
+	for _, key := range l.wordOrder {
+		if err := f(tilemapping.FromByteArr([]byte(key))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
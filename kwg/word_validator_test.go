@@ -0,0 +1,94 @@
+package kwg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/tilemapping"
+)
+
+var validatorTestWords = []string{
+	"AA", "AB", "ABA", "BA", "BAA", "CAB", "CABS", "QI", "SCAB", "ZA", "ZZZ",
+}
+
+func collectWords(t *testing.T, v WordValidator) []string {
+	t.Helper()
+	words := []string{}
+	err := v.ForEachWord(func(w tilemapping.MachineWord) error {
+		words = append(words, w.UserVisible(v.GetAlphabet()))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return words
+}
+
+func TestWordSetLexiconMatchesKWG(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", validatorTestWords...)
+	set, err := ReadWordSetLexicon("TEST", k.GetAlphabet(), strings.NewReader(
+		"zzz\nQI some definition\n\n"+strings.Join(validatorTestWords, "\n")))
+	is.NoErr(err)
+	is.Equal(set.NumWords(), len(validatorTestWords))
+
+	validators := []WordValidator{Lexicon{KWG: *k}, set}
+	for _, v := range validators {
+		is.Equal(v.Name(), "TEST")
+		is.Equal(collectWords(t, v), validatorTestWords)
+	}
+	for _, q := range []string{"AA", "ABA", "AAB", "CABS", "SBAC", "BACS", "Z", "ZZ", "QIS", "IQ"} {
+		mw := testMW(t, k, q)
+		is.Equal(validators[0].HasWord(mw), validators[1].HasWord(mw))
+		is.Equal(validators[0].HasAnagram(mw), validators[1].HasAnagram(mw))
+	}
+	is.True(!set.HasAnagram(testMW(t, k, "AB?")))
+}
+
+func TestHasWordRejectsShortWords(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", "A", "AB")
+	set := NewWordSetLexicon("TEST", k.GetAlphabet(),
+		[]tilemapping.MachineWord{testMW(t, k, "A"), testMW(t, k, "AB")})
+	for _, v := range []WordValidator{Lexicon{KWG: *k}, set} {
+		is.True(!v.HasWord(testMW(t, k, "A")))
+		is.True(!v.HasWord(tilemapping.MachineWord{}))
+		is.True(v.HasWord(testMW(t, k, "AB")))
+	}
+}
+
+func TestForEachWordStops(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "TEST", validatorTestWords...)
+	stop := errors.New("stop")
+	n := 0
+	err := Lexicon{KWG: *k}.ForEachWord(func(tilemapping.MachineWord) error {
+		n++
+		if n == 3 {
+			return stop
+		}
+		return nil
+	})
+	is.Equal(err, stop)
+	is.Equal(n, 3)
+}
+
+func TestReadWordSetLexiconRejectsBlanks(t *testing.T) {
+	is := is.New(t)
+	_, err := ReadWordSetLexicon("TEST", testEnglishLD(t).TileMapping(), strings.NewReader("A?\n"))
+	is.True(err != nil)
+}
+
+func TestAdjudicateWithWordSetLexicon(t *testing.T) {
+	is := is.New(t)
+	k := buildTestKWG(t, "LEXA", "QI")
+	set := NewWordSetLexicon("LEXB", k.GetAlphabet(), []tilemapping.MachineWord{testMW(t, k, "ZA")})
+
+	adj, err := AdjudicateWords([]tilemapping.MachineWord{testMW(t, k, "QI"), testMW(t, k, "zA")}, Lexicon{KWG: *k}, set)
+	is.NoErr(err)
+	is.True(adj.Valid)
+	is.Equal(adj.Words[1].ValidIn, []string{"LEXB"})
+}
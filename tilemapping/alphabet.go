@@ -18,6 +18,10 @@ import (
 const (
 	// MaxAlphabetSize should be below 64 so that a letterset can be a 64-bit int.
 	MaxAlphabetSize = 62
+	// MaxWideAlphabetSize is the largest alphabet a distribution can have,
+	// since the high bit of a MachineLetter marks a blank. Alphabets bigger
+	// than MaxAlphabetSize are wide: their letter sets are WideLetterSets.
+	MaxWideAlphabetSize = 128
 	// ASCIIPlayedThrough is a somewhat user-friendly representation of a
 	// played-through letter, used mostly for debug purposes.
 	// Note that in order to actually be visible on a computer screen, we
@@ -47,10 +51,10 @@ type MachineWord []MachineLetter
 // MachineLetter(2) in the english-alphabet), and vice-versa.
 type TileMapping struct {
 	// vals is a map of the actual physical letter rune (like 'A') to a
-	// number representing it, from 0 to MaxAlphabetSize.
+	// number representing it, from 0 to MaxWideAlphabetSize.
 	vals map[string]MachineLetter
-	// letters is a map of the 0 to MaxAlphabetSize value back to a letter.
-	letters [MaxAlphabetSize]string
+	// letters is a map of the 0 to NumLetters-1 value back to a letter.
+	letters []string
 	// maxTileLength is the maximum length of any tile for this mapping,
 	// in runes. For example, Catalan's L·L tile is 3 runes long.
 	maxTileLength int
@@ -164,6 +168,12 @@ func (rm *TileMapping) NumLetters() uint8 {
 	return uint8(len(rm.vals))
 }
 
+// IsWide returns whether the alphabet is too big for a LetterSet. Letter
+// sets over a wide alphabet must be WideLetterSets.
+func (rm *TileMapping) IsWide() bool {
+	return len(rm.vals) > MaxAlphabetSize
+}
+
 func (rm *TileMapping) Vals() map[string]MachineLetter {
 	return rm.vals
 }
@@ -257,6 +267,7 @@ func (rm *TileMapping) Reconcile(letters []string) {
 	// These maps are now deterministic. Renumber them according to
 	// sort order.
 	maxLength := 0
+	rm.letters = make([]string, len(letters))
 	for idx, letter := range letters {
 		rm.vals[letter] = MachineLetter(idx)
 		rm.letters[MachineLetter(idx)] = letter
@@ -2,10 +2,9 @@ package tilemapping
 
 import (
 	"encoding/csv"
-	"errors"
+	"fmt"
 	"io"
 	"strconv"
-	"strings"
 
 	"github.com/domino14/word-golib/config"
 )
@@ -60,6 +59,10 @@ func ScanLetterDistribution(data io.Reader) (*LetterDistribution, error) {
 		letters = append(letters, letter)
 		idx++
 	}
+	if len(letters) > MaxWideAlphabetSize {
+		return nil, fmt.Errorf("letter distribution has %d tiles, the maximum is %d",
+			len(letters), MaxWideAlphabetSize)
+	}
 	alph.Reconcile(letters)
 	return newLetterDistribution(alph, dist, ptValues, vowels), nil
 }
@@ -131,51 +134,24 @@ func (ld *LetterDistribution) NumTotalLetters() uint {
 
 // ProbableLetterDistributionName returns a letter distribution name given a lexicon name.
 // It makes a best guess for the letter distribution, assuming that it would be
-// the "standard" one for that lexicon.
+// the "standard" one for that lexicon. It uses the lexica registered with
+// RegisterLexicon and the built-in ones; see LookupLexicon.
 func ProbableLetterDistributionName(lexname string) (string, error) {
-	lexname = strings.ToLower(lexname)
-
-	var ldName string
-
-	prefixMap := map[string]string{
-		"nwl":     "english",
-		"nswl":    "english",
-		"twl":     "english",
-		"owl":     "english",
-		"csw":     "english",
-		"america": "english",
-		"cel":     "english",
-		"ecwl":    "english",
-		"iwi":     "english",
-		"osps":    "polish",
-		"nsf":     "norwegian",
-		"fra":     "french",
-		"rd":      "german",
-		"deutsch": "german",
-		"cgl":     "german",
-		"disc":    "catalan",
-		"fise":    "spanish",
-		"file":    "spanish",
-		"dsw":     "dutch",
-		"slv":     "slovene",
-	}
-	for prefix, name := range prefixMap {
-		if strings.HasPrefix(lexname, prefix) {
-			ldName = name
-			return ldName, nil
-		}
+	info, err := LookupLexicon(nil, lexname)
+	if err != nil {
+		return "", err
 	}
-
-	return "", errors.New("cannot determine alphabet from lexicon name " + lexname)
+	return info.Distribution, nil
 }
 
 // ProbableLetterDistribution returns a letter distribution given a lexicon name.
 // It makes a best guess for the letter distribution, assuming that it would be
-// the "standard" one for that lexicon.
+// the "standard" one for that lexicon. Unlike ProbableLetterDistributionName,
+// it also uses the LexiconRegistryFile of cfg's DataPath.
 func ProbableLetterDistribution(cfg *config.Config, lexname string) (*LetterDistribution, error) {
-	ldName, err := ProbableLetterDistributionName(lexname)
+	info, err := LookupLexicon(cfg, lexname)
 	if err != nil {
 		return nil, err
 	}
-	return NamedLetterDistribution(cfg, ldName)
+	return NamedLetterDistribution(cfg, info.Distribution)
 }
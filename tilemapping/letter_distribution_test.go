@@ -2,6 +2,7 @@ package tilemapping
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/domino14/word-golib/config"
@@ -55,3 +56,33 @@ func TestProbableLetterDistributionName(t *testing.T) {
 	_, err := ProbableLetterDistributionName("WOW24")
 	is.True(err != nil)
 }
+
+func wideDistribution(numLetters int) string {
+	var sb strings.Builder
+	sb.WriteString("?,2,0,0\n")
+	for i := 1; i < numLetters; i++ {
+		fmt.Fprintf(&sb, "%c,1,1,0\n", rune(0x100+i))
+	}
+	return sb.String()
+}
+
+func TestScanWideLetterDistribution(t *testing.T) {
+	is := is.New(t)
+	ld, err := ScanLetterDistribution(strings.NewReader(wideDistribution(100)))
+	is.NoErr(err)
+	tm := ld.TileMapping()
+	is.Equal(tm.NumLetters(), uint8(100))
+	is.True(tm.IsWide())
+
+	last := string(rune(0x100 + 99))
+	mls, err := ToMachineLetters(last, tm)
+	is.NoErr(err)
+	is.Equal(mls, []MachineLetter{99})
+	is.Equal(tm.Letter(99), last)
+}
+
+func TestScanLetterDistributionTooBig(t *testing.T) {
+	is := is.New(t)
+	_, err := ScanLetterDistribution(strings.NewReader(wideDistribution(MaxWideAlphabetSize + 1)))
+	is.True(err != nil)
+}
@@ -0,0 +1,52 @@
+package tilemapping
+
+import "math/bits"
+
+// WideLetterSet is a bit mask of acceptable letters for alphabets of up to
+// MaxWideAlphabetSize letters. Alphabets of up to MaxAlphabetSize letters
+// use the faster LetterSet.
+type WideLetterSet [2]uint64
+
+// WideLetterSetFrom widens a LetterSet.
+func WideLetterSetFrom(ls LetterSet) WideLetterSet {
+	return WideLetterSet{uint64(ls), 0}
+}
+
+// Add adds a letter to the set. Blanked letters are added unblanked.
+func (s *WideLetterSet) Add(ml MachineLetter) {
+	ml = ml.Unblank()
+	s[ml>>6] |= 1 << (ml & 63)
+}
+
+// Contains returns whether a letter is in the set. Blanked letters are
+// looked up unblanked.
+func (s WideLetterSet) Contains(ml MachineLetter) bool {
+	ml = ml.Unblank()
+	return s[ml>>6]&(1<<(ml&63)) != 0
+}
+
+// Union returns the letters in either set.
+func (s WideLetterSet) Union(o WideLetterSet) WideLetterSet {
+	return WideLetterSet{s[0] | o[0], s[1] | o[1]}
+}
+
+// Intersect returns the letters in both sets.
+func (s WideLetterSet) Intersect(o WideLetterSet) WideLetterSet {
+	return WideLetterSet{s[0] & o[0], s[1] & o[1]}
+}
+
+// IsEmpty returns whether the set has no letters.
+func (s WideLetterSet) IsEmpty() bool {
+	return s[0]|s[1] == 0
+}
+
+// Count returns the number of letters in the set.
+func (s WideLetterSet) Count() int {
+	return bits.OnesCount64(s[0]) + bits.OnesCount64(s[1])
+}
+
+// Narrow returns the set as a LetterSet, and whether all of its letters
+// fit in one.
+func (s WideLetterSet) Narrow() (LetterSet, bool) {
+	return LetterSet(s[0]), s[1] == 0
+}
@@ -0,0 +1,32 @@
+package tilemapping
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWideLetterSet(t *testing.T) {
+	is := is.New(t)
+	var s WideLetterSet
+	is.True(s.IsEmpty())
+	s.Add(3)
+	s.Add(70)
+	s.Add(MachineLetter(100).Blank())
+	is.Equal(s.Count(), 3)
+	is.True(s.Contains(3))
+	is.True(s.Contains(MachineLetter(70).Blank()))
+	is.True(s.Contains(100))
+	is.True(!s.Contains(4))
+	is.True(!s.Contains(6))
+
+	_, ok := s.Narrow()
+	is.True(!ok)
+	narrow := WideLetterSetFrom(1<<3 | 1<<60)
+	ls, ok := narrow.Narrow()
+	is.True(ok)
+	is.Equal(ls, LetterSet(1<<3|1<<60))
+
+	is.Equal(s.Intersect(narrow).Count(), 1)
+	is.Equal(s.Union(narrow).Count(), 4)
+}
@@ -0,0 +1,221 @@
+package tilemapping
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/domino14/word-golib/cache"
+	"github.com/domino14/word-golib/config"
+)
+
+// LexiconRegistryFile is the name of the file in DataPath/lexica that
+// describes lexica. Every line is a CSV record
+//
+//	pattern,distribution,language,board,rules
+//
+// where lines starting with # are comments, and the last three fields may
+// be empty. For example:
+//
+//	nwl*,english,en,CrosswordGame,
+//	nswl*,english,en,CrosswordGame,
+//	ENWL2025,english,en,,
+const LexiconRegistryFile = "lexicons.csv"
+
+// CacheKeyPrefixLexiconRegistry is the cache key prefix of the parsed
+// LexiconRegistryFile of a data path.
+const CacheKeyPrefixLexiconRegistry = "lexregistry:"
+
+// LexiconInfo describes the lexica whose names match Pattern.
+type LexiconInfo struct {
+	// Pattern is a lexicon name, or a lexicon name prefix followed by *. It
+	// is case-insensitive.
+	Pattern string
+	// Distribution is the name of the letter distribution of the lexica.
+	Distribution string
+	// Language, Board and Rules are the language code, default board
+	// layout name and default rules name for the lexica. They are empty if
+	// unknown; word-golib doesn't interpret them.
+	Language string
+	Board    string
+	Rules    string
+}
+
+// builtinLexica is the fallback for lexica that neither the registry file
+// nor RegisterLexicon describe.
+var builtinLexica = []LexiconInfo{
+	{Pattern: "nwl*", Distribution: "english", Language: "en"},
+	{Pattern: "nswl*", Distribution: "english", Language: "en"},
+	{Pattern: "twl*", Distribution: "english", Language: "en"},
+	{Pattern: "owl*", Distribution: "english", Language: "en"},
+	{Pattern: "csw*", Distribution: "english", Language: "en"},
+	{Pattern: "america*", Distribution: "english", Language: "en"},
+	{Pattern: "cel*", Distribution: "english", Language: "en"},
+	{Pattern: "ecwl*", Distribution: "english", Language: "en"},
+	{Pattern: "iwi*", Distribution: "english", Language: "en"},
+	{Pattern: "osps*", Distribution: "polish", Language: "pl"},
+	{Pattern: "nsf*", Distribution: "norwegian", Language: "nb"},
+	{Pattern: "fra*", Distribution: "french", Language: "fr"},
+	{Pattern: "rd*", Distribution: "german", Language: "de"},
+	{Pattern: "deutsch*", Distribution: "german", Language: "de"},
+	{Pattern: "cgl*", Distribution: "german", Language: "de"},
+	{Pattern: "disc*", Distribution: "catalan", Language: "ca"},
+	{Pattern: "fise*", Distribution: "spanish", Language: "es"},
+	{Pattern: "file*", Distribution: "spanish", Language: "es"},
+	{Pattern: "dsw*", Distribution: "dutch", Language: "nl"},
+	{Pattern: "slv*", Distribution: "slovene", Language: "sl"},
+}
+
+// registeredLexica holds the lexica registered in code, keyed by lowercased
+// pattern. They take precedence over the registry file.
+var registeredLexica = struct {
+	sync.RWMutex
+	m map[string]LexiconInfo
+}{m: map[string]LexiconInfo{}}
+
+// RegisterLexicon describes the lexica matching info.Pattern, replacing any
+// registration with the same pattern.
+func RegisterLexicon(info LexiconInfo) error {
+	if err := validateLexiconInfo(info); err != nil {
+		return err
+	}
+	registeredLexica.Lock()
+	defer registeredLexica.Unlock()
+	registeredLexica.m[strings.ToLower(info.Pattern)] = info
+	return nil
+}
+
+// UnregisterLexicon removes the registration with the given pattern.
+func UnregisterLexicon(pattern string) {
+	registeredLexica.Lock()
+	defer registeredLexica.Unlock()
+	delete(registeredLexica.m, strings.ToLower(pattern))
+}
+
+func validateLexiconInfo(info LexiconInfo) error {
+	p := strings.TrimSuffix(info.Pattern, "*")
+	if p == "" || strings.Contains(p, "*") {
+		return fmt.Errorf("invalid lexicon pattern %q", info.Pattern)
+	}
+	if info.Distribution == "" {
+		return fmt.Errorf("lexicon pattern %q has no distribution", info.Pattern)
+	}
+	return nil
+}
+
+// ScanLexiconRegistry reads lexicon descriptions in the format of
+// LexiconRegistryFile.
+func ScanLexiconRegistry(data io.Reader) ([]LexiconInfo, error) {
+	r := csv.NewReader(data)
+	r.Comment = '#'
+	r.FieldsPerRecord = 5
+	r.TrimLeadingSpace = true
+	var infos []LexiconInfo
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+		info := LexiconInfo{
+			Pattern:      record[0],
+			Distribution: record[1],
+			Language:     record[2],
+			Board:        record[3],
+			Rules:        record[4],
+		}
+		if err := validateLexiconInfo(info); err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// loadLexiconRegistryFile returns the lexica described in the
+// LexiconRegistryFile of cfg's data path. A missing file describes none.
+func loadLexiconRegistryFile(cfg *config.Config) ([]LexiconInfo, error) {
+	if cfg == nil || cfg.DataPath == "" {
+		return nil, nil
+	}
+	obj, err := cache.Load(cfg, CacheKeyPrefixLexiconRegistry+cfg.DataPath,
+		func(cfg *config.Config, _ string) (interface{}, error) {
+			file, _, err := cache.Open(filepath.Join(cfg.DataPath, "lexica", LexiconRegistryFile))
+			if errors.Is(err, os.ErrNotExist) {
+				return []LexiconInfo{}, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			return ScanLexiconRegistry(file)
+		})
+	if err != nil {
+		return nil, err
+	}
+	infos, ok := obj.([]LexiconInfo)
+	if !ok {
+		return nil, errors.New("could not convert cached object to lexicon registry")
+	}
+	return infos, nil
+}
+
+// LookupLexicon describes the named lexicon. It uses the lexica registered
+// with RegisterLexicon, then those in the LexiconRegistryFile of cfg's
+// DataPath (cfg may be nil), then the built-in ones. The most specific
+// pattern that matches wins: an exact name, then the longest prefix. Among
+// equally specific patterns, the first source in that order wins.
+func LookupLexicon(cfg *config.Config, lexname string) (LexiconInfo, error) {
+	fileInfos, err := loadLexiconRegistryFile(cfg)
+	if err != nil {
+		return LexiconInfo{}, err
+	}
+	registeredLexica.RLock()
+	registered := make([]LexiconInfo, 0, len(registeredLexica.m))
+	for _, info := range registeredLexica.m {
+		registered = append(registered, info)
+	}
+	registeredLexica.RUnlock()
+
+	lexname = strings.ToLower(lexname)
+	var best LexiconInfo
+	bestScore := -1
+	for _, infos := range [][]LexiconInfo{registered, fileInfos, builtinLexica} {
+		for _, info := range infos {
+			// Registered patterns are unique, so their order doesn't matter.
+			if score := patternScore(strings.ToLower(info.Pattern), lexname); score > bestScore {
+				best, bestScore = info, score
+			}
+		}
+	}
+	if bestScore < 0 {
+		return LexiconInfo{}, errors.New("cannot determine alphabet from lexicon name " + lexname)
+	}
+	return best, nil
+}
+
+// patternScore returns how specifically pattern matches lexname, or -1 if
+// it doesn't. Exact names beat all prefixes, and longer prefixes beat
+// shorter ones.
+func patternScore(pattern, lexname string) int {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		if strings.HasPrefix(lexname, prefix) {
+			return len(prefix)
+		}
+		return -1
+	}
+	if pattern == lexname {
+		return 1 << 16
+	}
+	return -1
+}
@@ -0,0 +1,99 @@
+package tilemapping
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/word-golib/cache"
+	"github.com/domino14/word-golib/config"
+)
+
+func TestLookupLexiconLongestPrefix(t *testing.T) {
+	is := is.New(t)
+	is.NoErr(RegisterLexicon(LexiconInfo{Pattern: "zzz*", Distribution: "english"}))
+	defer UnregisterLexicon("zzz*")
+	is.NoErr(RegisterLexicon(LexiconInfo{Pattern: "ZZZPL*", Distribution: "polish", Language: "pl"}))
+	defer UnregisterLexicon("zzzpl*")
+	is.NoErr(RegisterLexicon(LexiconInfo{Pattern: "zzzpl19", Distribution: "german"}))
+	defer UnregisterLexicon("zzzpl19")
+
+	for _, c := range []struct{ lexname, want string }{
+		{"ZZZ21", "english"},
+		{"zzzpl21", "polish"},
+		{"ZZZPL19", "german"},
+		{"ZZZPL190", "polish"},
+	} {
+		// Map iteration order is random, so look up repeatedly.
+		for range 20 {
+			got, err := ProbableLetterDistributionName(c.lexname)
+			is.NoErr(err)
+			is.Equal(got, c.want)
+		}
+	}
+	info, err := LookupLexicon(nil, "ZZZPL21")
+	is.NoErr(err)
+	is.Equal(info.Language, "pl")
+
+	is.True(RegisterLexicon(LexiconInfo{Pattern: "*", Distribution: "english"}) != nil)
+	is.True(RegisterLexicon(LexiconInfo{Pattern: "z*z*", Distribution: "english"}) != nil)
+	is.True(RegisterLexicon(LexiconInfo{Pattern: "zzz*"}) != nil)
+}
+
+func TestLookupLexiconRegistryFile(t *testing.T) {
+	is := is.New(t)
+	dataPath := t.TempDir()
+	is.NoErr(os.MkdirAll(filepath.Join(dataPath, "lexica"), 0755))
+	is.NoErr(os.WriteFile(filepath.Join(dataPath, "lexica", LexiconRegistryFile), []byte(
+		"# pattern,distribution,language,board,rules\n"+
+			"csw*,english_super,en,SuperCrosswordGame,\n"+
+			"ZZZFILE*, dutch, nl, CrosswordGame, classic\n"), 0644))
+	cfg := &config.Config{DataPath: dataPath}
+
+	info, err := LookupLexicon(cfg, "zzzfile24")
+	is.NoErr(err)
+	is.Equal(info, LexiconInfo{Pattern: "ZZZFILE*", Distribution: "dutch", Language: "nl",
+		Board: "CrosswordGame", Rules: "classic"})
+
+	// The file overrides the built-in entry with the same pattern...
+	info, err = LookupLexicon(cfg, "CSW24")
+	is.NoErr(err)
+	is.Equal(info.Board, "SuperCrosswordGame")
+	// ...and falls back to the built-in ones.
+	info, err = LookupLexicon(cfg, "NWL23")
+	is.NoErr(err)
+	is.Equal(info.Distribution, "english")
+	// Without a config, the file isn't used.
+	info, err = LookupLexicon(nil, "CSW24")
+	is.NoErr(err)
+	is.Equal(info.Distribution, "english")
+
+	_, err = LookupLexicon(cfg, "WOW24")
+	is.True(err != nil)
+}
+
+func TestLookupLexiconPrecachedRegistryFile(t *testing.T) {
+	is := is.New(t)
+	cfg := &config.Config{DataPath: t.TempDir()}
+	cache.Precache(filepath.Join(cfg.DataPath, "lexica", LexiconRegistryFile),
+		[]byte("ZZZPRE*,french,fr,,\n"))
+
+	info, err := LookupLexicon(cfg, "ZZZPRE24")
+	is.NoErr(err)
+	is.Equal(info.Distribution, "french")
+}
+
+func TestScanLexiconRegistryErrors(t *testing.T) {
+	is := is.New(t)
+	for _, data := range []string{
+		"csw*,english,en\n",
+		"csw*,,en,,\n",
+		",english,en,,\n",
+	} {
+		_, err := ScanLexiconRegistry(strings.NewReader(data))
+		is.True(err != nil)
+	}
+}